@@ -0,0 +1,157 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"syscall"
+
+	"github.com/BurntSushi/toml"
+	"github.com/notnil/chess/uci"
+
+	"termchess/game"
+)
+
+// Config holds the defaults read from the config file. Command line flags
+// take precedence over it.
+type Config struct {
+	Mode   string       `toml:"mode"`   // default game mode: two-player or engine
+	Theme  string       `toml:"theme"`  // default board theme
+	Themes string       `toml:"themes"` // file with user themes
+	Pieces string       `toml:"pieces"` // default piece glyph set
+	Engine EngineConfig `toml:"engine"`
+	Log    LogConfig    `toml:"log"`
+}
+
+// EngineConfig locates the UCI engine and sets its resource options.
+type EngineConfig struct {
+	Path    string `toml:"path"`    // engine executable, looked up in PATH when it has no slash
+	Threads int    `toml:"threads"` // UCI "Threads", 0 keeps the engine default
+	Hash    int    `toml:"hash"`    // UCI "Hash" in MB, 0 keeps the engine default
+	MultiPV int    `toml:"multipv"` // UCI "MultiPV", 0 keeps the engine default
+}
+
+// LogConfig chooses where the log is written and how much of it.
+type LogConfig struct {
+	File  string `toml:"file"`
+	Level string `toml:"level"` // debug, info, warn or error
+}
+
+func defaultConfig() Config {
+	return Config{
+		Mode:   game.ModeTwoPlayer.String(),
+		Theme:  game.DefaultThemeName,
+		Pieces: game.DefaultPieceSetName,
+		Engine: EngineConfig{Path: "stockfish"},
+		Log: LogConfig{
+			File:  filepath.Join(stateDir(), "termchess", "termchess.log"),
+			Level: slog.LevelInfo.String(),
+		},
+	}
+}
+
+// defaultConfigPath returns termchess/config.toml in the XDG config directory.
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "termchess", "config.toml")
+}
+
+// stateDir returns the XDG state directory, where the log is kept.
+func stateDir() string {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return dir
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return os.TempDir()
+	}
+
+	return filepath.Join(home, ".local", "state")
+}
+
+// loadConfig reads the config file at path into cfg, keeping the values the
+// file leaves out. A missing file is only an error when it was asked for.
+func loadConfig(path string, required bool, cfg *Config) error {
+	if path == "" {
+		return nil
+	}
+
+	_, err := toml.DecodeFile(path, cfg)
+	if errors.Is(err, fs.ErrNotExist) && !required {
+		return nil
+	}
+
+	return err
+}
+
+// setupLogging writes the log to the configured file. When the file can not
+// be opened the log is discarded, since the terminal belongs to the game.
+func setupLogging(cfg LogConfig) (*os.File, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+		return nil, fmt.Errorf("unknown log level %q, expected debug, info, warn or error", cfg.Level)
+	}
+
+	var out io.Writer = io.Discard
+	var file *os.File
+	err := os.MkdirAll(filepath.Dir(cfg.File), 0755)
+	if err == nil {
+		file, err = os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "termchess: logging disabled: %v\n", err)
+	} else {
+		out = file
+	}
+
+	slog.SetDefault(slog.New(slog.NewTextHandler(out, &slog.HandlerOptions{Level: level})))
+
+	return file, nil
+}
+
+// startEngine starts the UCI engine with the resource options. It falls back
+// to game.NoAnalyzer when there is no engine executable at the path: it is not
+// in PATH, does not exist, is a directory or can not be run.
+func startEngine(cfg EngineConfig) (game.Analyzer, error) {
+	a, err := game.NewUCIAnalyzer(cfg.Path, cfg.setOptionCmds()...)
+	if errors.Is(err, exec.ErrNotFound) || errors.Is(err, fs.ErrNotExist) ||
+		errors.Is(err, fs.ErrPermission) || errors.Is(err, syscall.EISDIR) {
+		slog.Warn("engine not available", "path", cfg.Path, "err", err)
+		return game.NoAnalyzer{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// setOptionCmds returns the UCI commands that apply the configured resource options.
+func (c EngineConfig) setOptionCmds() []uci.Cmd {
+	var cmds []uci.Cmd
+	for _, option := range []struct {
+		name  string
+		value int
+	}{
+		{"Threads", c.Threads},
+		{"Hash", c.Hash},
+		{"MultiPV", c.MultiPV},
+	} {
+		if option.value > 0 {
+			cmds = append(cmds, uci.CmdSetOption{Name: option.name, Value: strconv.Itoa(option.value)})
+		}
+	}
+
+	return cmds
+}
@@ -0,0 +1,246 @@
+package game
+
+import (
+	"errors"
+	"log"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/huh"
+	"github.com/notnil/chess"
+	"github.com/notnil/chess/uci"
+)
+
+// ErrNoEngine is returned by the searches of NoAnalyzer.
+var ErrNoEngine = errors.New("no chess engine attached")
+
+// Analysis is the result of a search: the move the engine chose and its
+// principal variations. Info holds the best line.
+type Analysis struct {
+	uci.SearchResults
+	Lines []uci.Info // principal variations ranked by the engine, the best first
+}
+
+// Analyzer searches chess positions for the best move and their evaluation.
+// The game only talks to an engine through it, so it runs without one.
+type Analyzer interface {
+	// Search analyses the position and blocks until the search ends.
+	Search(pos *chess.Position, cmdGo uci.CmdGo) (Analysis, error)
+	// Stop asks the running search to end early.
+	Stop() error
+	// SetOptions sends UCI options, e.g. MultiPV or the strength settings, to the engine.
+	SetOptions(options ...uci.Cmd) error
+	// Progress returns the lines the running search has found so far, the best first.
+	Progress() []uci.Info
+	// Name returns the name the engine reports, e.g. "Stockfish 16".
+	Name() string
+	// Close shuts the engine down.
+	Close() error
+}
+
+// NoAnalyzer stands in for the engine when none is attached.
+type NoAnalyzer struct{}
+
+func (NoAnalyzer) Search(*chess.Position, uci.CmdGo) (Analysis, error) {
+	return Analysis{}, ErrNoEngine
+}
+
+func (NoAnalyzer) Stop() error                 { return nil }
+func (NoAnalyzer) SetOptions(...uci.Cmd) error { return nil }
+func (NoAnalyzer) Progress() []uci.Info        { return nil }
+func (NoAnalyzer) Name() string                { return "" }
+func (NoAnalyzer) Close() error                { return nil }
+
+// UCIAnalyzer runs the searches on a UCI engine such as Stockfish.
+type UCIAnalyzer struct {
+	eng   *uci.Engine
+	lines *infoLines
+}
+
+// infoLines collects the principal variations reported during a search from
+// the engine output, since uci.Engine only keeps the last info line.
+type infoLines struct {
+	mu    sync.Mutex
+	lines map[int]uci.Info // latest line by MultiPV rank
+}
+
+// Write receives the engine output line by line through the uci debug logger.
+func (c *infoLines) Write(p []byte) (int, error) {
+	text := strings.TrimSpace(string(p))
+	if !strings.HasPrefix(text, "info") {
+		return len(p), nil
+	}
+
+	var info uci.Info
+	if err := info.UnmarshalText([]byte(text)); err != nil || len(info.PV) == 0 {
+		return len(p), nil
+	}
+
+	// bound scores are provisional, the exact score follows
+	if info.Score.LowerBound || info.Score.UpperBound {
+		return len(p), nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lines[max(info.Multipv, 1)] = info
+
+	return len(p), nil
+}
+
+func (c *infoLines) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lines = map[int]uci.Info{}
+}
+
+// ranked returns the collected lines, the best first.
+func (c *infoLines) ranked() []uci.Info {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ranks := make([]int, 0, len(c.lines))
+	for rank := range c.lines {
+		ranks = append(ranks, rank)
+	}
+	slices.Sort(ranks)
+
+	lines := make([]uci.Info, 0, len(ranks))
+	for _, rank := range ranks {
+		lines = append(lines, c.lines[rank])
+	}
+
+	return lines
+}
+
+// NewUCIAnalyzer starts the engine executable at path and prepares it for a
+// new game with the given options. When there is no executable at path, the
+// error wraps the one of exec.LookPath: exec.ErrNotFound for a name that is
+// not in PATH, fs.ErrNotExist, fs.ErrPermission or syscall.EISDIR for a path
+// with a slash.
+func NewUCIAnalyzer(path string, options ...uci.Cmd) (*UCIAnalyzer, error) {
+	lines := &infoLines{lines: map[int]uci.Info{}}
+	eng, err := uci.New(path, uci.Debug, uci.Logger(log.New(lines, "", 0)))
+	if err != nil {
+		return nil, err
+	}
+
+	cmds := append([]uci.Cmd{uci.CmdUCI, uci.CmdIsReady}, options...)
+	cmds = append(cmds, uci.CmdUCINewGame)
+	if err := eng.Run(cmds...); err != nil {
+		_ = eng.Close()
+		return nil, err
+	}
+
+	return &UCIAnalyzer{eng: eng, lines: lines}, nil
+}
+
+func (a *UCIAnalyzer) Search(pos *chess.Position, cmdGo uci.CmdGo) (Analysis, error) {
+	a.lines.reset()
+	if err := a.eng.Run(uci.CmdPosition{Position: pos}, cmdGo); err != nil {
+		return Analysis{}, err
+	}
+
+	analysis := Analysis{SearchResults: a.eng.SearchResults(), Lines: a.lines.ranked()}
+	if len(analysis.Lines) > 0 {
+		analysis.Info = analysis.Lines[0]
+	}
+
+	return analysis, nil
+}
+
+func (a *UCIAnalyzer) Stop() error {
+	return a.eng.Run(uci.CmdStop)
+}
+
+func (a *UCIAnalyzer) Name() string {
+	return a.eng.ID()["name"]
+}
+
+// Close stops a search that is still running, which would otherwise hold
+// the engine, and quits the engine.
+func (a *UCIAnalyzer) Close() error {
+	if err := a.eng.Run(uci.CmdStop); err != nil {
+		return err
+	}
+
+	return a.eng.Close()
+}
+
+func (a *UCIAnalyzer) SetOptions(options ...uci.Cmd) error {
+	return a.eng.Run(options...)
+}
+
+func (a *UCIAnalyzer) Progress() []uci.Info {
+	return a.lines.ranked()
+}
+
+// hasEngine reports whether an engine is attached.
+func (m *Model) hasEngine() bool {
+	_, none := m.analyzer.(NoAnalyzer)
+	return m.analyzer != nil && !none
+}
+
+// Close shuts down the attached engine.
+func (m *Model) Close() error {
+	return m.analyzer.Close()
+}
+
+// attachEngine starts an engine while the game is running, to analyse the
+// game or to play against it.
+func (m *Model) attachEngine() {
+	if m.hasEngine() {
+		m.status = "An engine is already attached"
+		return
+	}
+
+	path := m.enginePath
+	var play bool
+	form := huh.NewForm(huh.NewGroup(
+		huh.NewInput().
+			Title("Engine executable").
+			Placeholder("stockfish").
+			Value(&path),
+		huh.NewConfirm().
+			Title("Play against the engine?").
+			Affirmative("Play").
+			Negative("Only analyse").
+			Value(&play),
+	))
+
+	if err := form.Run(); err != nil {
+		slog.Error("input error", "err", err)
+		return
+	}
+
+	a, err := NewUCIAnalyzer(path, m.engineOptions...)
+	if err != nil {
+		slog.Error("error starting engine", "path", path, "err", err)
+		m.status = "Could not start the engine: " + err.Error()
+		return
+	}
+
+	m.analyzer = a
+	m.enginePath = path
+	m.status = "Engine attached for analysis"
+	if !play || m.outcome() != chess.NoOutcome {
+		return
+	}
+
+	side, err := SelectSide()
+	if err != nil {
+		slog.Error("input error", "err", err)
+		return
+	}
+
+	if err := a.SetOptions(m.engineSettings.SetOptionCmds()...); err != nil {
+		slog.Error("error setting engine options", "err", err)
+	}
+
+	m.mode = ModeEngine
+	m.humanPlayer = side
+	m.orient()
+	m.status = "Engine attached, you play " + side.String()
+}
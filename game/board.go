@@ -2,6 +2,8 @@ package game
 
 import (
 	"fmt"
+
+	"github.com/notnil/chess"
 )
 
 type Board struct {
@@ -50,6 +52,17 @@ func NewBoard() *Board {
 	return &Board{grid: grid}
 }
 
+// NewBoardFromPosition builds a board mirroring the pieces of the given chess position.
+func NewBoardFromPosition(pos *chess.Position) *Board {
+	b := &Board{}
+	for sq, p := range pos.Board().SquareMap() {
+		x, y := coordinates(sq.String())
+		b.Set(x, y, pieceFromChess(p))
+	}
+
+	return b
+}
+
 // Position converts board coordinates to chess notation (e.g., (6, 4) -> "e2")
 func Position(x, y int) string {
 	if x < 0 || x >= 8 || y < 0 || y >= 8 {
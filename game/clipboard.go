@@ -0,0 +1,42 @@
+package game
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/aymanbagabas/go-osc52/v2"
+)
+
+// copyToClipboard places text on the system clipboard with an OSC52 escape
+// sequence, which the terminal handles itself and therefore also works over SSH.
+func copyToClipboard(text string) error {
+	seq := osc52.New(text)
+	if os.Getenv("TMUX") != "" {
+		seq = seq.Tmux()
+	} else if strings.HasPrefix(os.Getenv("TERM"), "screen") {
+		seq = seq.Screen()
+	}
+
+	// stdout belongs to the renderer, stderr reaches the same terminal
+	_, err := seq.WriteTo(os.Stderr)
+	return err
+}
+
+func (m *Model) copyFEN() {
+	m.copy("FEN", m.gameEngine.Position().String())
+}
+
+func (m *Model) copyPGN() {
+	m.copy("PGN", m.PGN())
+}
+
+func (m *Model) copy(what, text string) {
+	if err := copyToClipboard(text); err != nil {
+		slog.Error("error copying to clipboard", "what", what, "err", err)
+		m.status = "Could not copy " + what + ": " + err.Error()
+		return
+	}
+
+	m.status = "Copied " + what + " to the clipboard"
+}
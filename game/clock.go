@@ -0,0 +1,426 @@
+package game
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/notnil/chess"
+)
+
+// clockTickInterval is how often a running clock is redrawn and checked for a fallen flag.
+const clockTickInterval = time.Second / 10
+
+type DelayMode int
+
+const (
+	DelayFischer   DelayMode = iota // the increment is added after every move
+	DelayBronstein                  // the time used is given back after every move, up to the increment
+)
+
+func (d DelayMode) String() string {
+	switch d {
+	case DelayFischer:
+		return "fischer"
+	case DelayBronstein:
+		return "bronstein"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseDelayMode converts a delay name (e.g., "bronstein") to a DelayMode.
+func ParseDelayMode(s string) (DelayMode, error) {
+	switch s {
+	case "fischer":
+		return DelayFischer, nil
+	case "bronstein":
+		return DelayBronstein, nil
+	default:
+		return DelayFischer, fmt.Errorf("unknown delay %q, expected fischer or bronstein", s)
+	}
+}
+
+// TimeControl is the base time of each player plus the per-move increment or delay.
+type TimeControl struct {
+	Base      time.Duration
+	Increment time.Duration
+	Delay     DelayMode
+}
+
+// ParseTimeControl converts "minutes+seconds" (e.g., "5+3" or "15+10") to a TimeControl.
+func ParseTimeControl(s string) (TimeControl, error) {
+	base, increment, found := strings.Cut(s, "+")
+	if !found {
+		increment = "0"
+	}
+
+	minutes, err := strconv.ParseFloat(base, 64)
+	if err != nil || minutes <= 0 {
+		return TimeControl{}, fmt.Errorf("invalid base time %q, expected minutes like 5 in 5+3", base)
+	}
+
+	seconds, err := strconv.Atoi(increment)
+	if err != nil || seconds < 0 {
+		return TimeControl{}, fmt.Errorf("invalid increment %q, expected seconds like 3 in 5+3", increment)
+	}
+
+	return TimeControl{
+		Base:      time.Duration(minutes * float64(time.Minute)),
+		Increment: time.Duration(seconds) * time.Second,
+	}, nil
+}
+
+func (tc TimeControl) String() string {
+	return fmt.Sprintf("%g+%d", tc.Base.Minutes(), int(tc.Increment/time.Second))
+}
+
+// Clock is a chess clock for both players. Only the clock of the player to
+// move runs; pressing it applies the increment or delay and starts the other.
+type Clock struct {
+	control   TimeControl
+	remaining [2]time.Duration
+	turn      Player
+	running   bool
+	turnStart time.Time     // when the running clock was last started
+	ply       int           // moves of the game the clock is at
+	history   []clockRecord // time left after each move, to take moves back and redo them
+}
+
+// clockRecord is the time left on both clocks after a move.
+type clockRecord struct {
+	move      string
+	remaining [2]time.Duration
+}
+
+func NewClock(tc TimeControl) *Clock {
+	return &Clock{
+		control:   tc,
+		remaining: [2]time.Duration{tc.Base, tc.Base},
+	}
+}
+
+// Remaining returns the time left for the player at the given moment.
+func (c *Clock) Remaining(p Player, now time.Time) time.Duration {
+	remaining := c.remaining[p]
+	if c.running && c.turn == p {
+		remaining -= now.Sub(c.turnStart)
+	}
+
+	return max(remaining, 0)
+}
+
+// Start runs the clock of the player to move.
+func (c *Clock) Start(now time.Time) {
+	if c.running {
+		return
+	}
+
+	c.running = true
+	c.turnStart = now
+}
+
+// Stop pauses the clock, keeping the time used so far.
+func (c *Clock) Stop(now time.Time) {
+	if !c.running {
+		return
+	}
+
+	c.remaining[c.turn] = c.Remaining(c.turn, now)
+	c.running = false
+}
+
+// Press ends the turn of the player to move and starts the opponent's clock.
+func (c *Clock) Press(now time.Time) {
+	running := c.running
+	used := time.Duration(0)
+	if running {
+		used = now.Sub(c.turnStart)
+	}
+
+	c.Stop(now)
+
+	switch c.control.Delay {
+	case DelayFischer:
+		c.remaining[c.turn] += c.control.Increment
+	case DelayBronstein:
+		c.remaining[c.turn] += min(used, c.control.Increment)
+	}
+
+	c.turn = c.turn.Switch()
+	if running {
+		c.Start(now)
+	}
+}
+
+// record remembers the time left after the move played at ply, forgetting
+// the moves recorded after it.
+func (c *Clock) record(ply int, move string) {
+	c.history = append(c.history[:min(ply-1, len(c.history))], clockRecord{move: move, remaining: c.remaining})
+	c.ply = ply
+}
+
+// recorded reports whether move was recorded at ply, i.e. it is played again.
+func (c *Clock) recorded(ply int, move string) bool {
+	return ply <= len(c.history) && c.history[ply-1].move == move
+}
+
+// rewind sets the clock back or forth to the time left after the given ply,
+// without any increment, and hands the turn to the player. Moves the clock
+// has no record of keep the time left as it is.
+func (c *Clock) rewind(ply int, turn Player, now time.Time) {
+	running := c.running
+	c.Stop(now)
+
+	switch {
+	case ply == 0:
+		c.remaining = [2]time.Duration{c.control.Base, c.control.Base}
+	case ply <= len(c.history):
+		c.remaining = c.history[ply-1].remaining
+	}
+
+	c.turn = turn
+	c.ply = ply
+	if running {
+		c.Start(now)
+	}
+}
+
+// Flagged reports whether the player to move has run out of time.
+func (c *Clock) Flagged(now time.Time) bool {
+	return c.Remaining(c.turn, now) <= 0
+}
+
+// clockTickMsg redraws the clock and checks whether a flag fell.
+type clockTickMsg time.Time
+
+func clockTick() tea.Cmd {
+	return tea.Tick(clockTickInterval, func(t time.Time) tea.Msg {
+		return clockTickMsg(t)
+	})
+}
+
+// syncClock keeps the clock in step with the game: it runs for the player to
+// move while the game is in progress and pauses during replays and after the
+// game ended. It returns the tick command while the clock is running.
+func (m *Model) syncClock(now time.Time) tea.Cmd {
+	if m.clock == nil {
+		return nil
+	}
+
+	if m.outcome() != chess.NoOutcome || m.replay != nil {
+		m.clock.Stop(now)
+		return nil
+	}
+
+	// a new move presses the clock, taking moves back or redoing them
+	// restores the time left when they were played
+	moves := m.gameEngine.Moves()
+	ply := len(moves)
+	if ply != m.clock.ply || m.clock.turn != m.currentPlayer {
+		last := ""
+		if ply > 0 {
+			last = moves[ply-1].String()
+		}
+
+		if ply == m.clock.ply+1 && !m.clock.recorded(ply, last) {
+			// a move made after the flag fell, before the next tick, is too late
+			if m.clock.running && m.clock.Flagged(now) {
+				m.flag(now)
+				return nil
+			}
+			m.clock.Press(now)
+			m.clock.record(ply, last)
+		} else {
+			m.clock.rewind(ply, m.currentPlayer, now)
+		}
+	}
+	m.clock.Start(now)
+
+	if m.clockTicking {
+		return nil
+	}
+	m.clockTicking = true
+	return clockTick()
+}
+
+// handleClockTick ends the game when the player to move has run out of time.
+func (m *Model) handleClockTick(now time.Time) tea.Cmd {
+	m.clockTicking = false
+	if m.clock != nil && m.clock.running && m.clock.Flagged(now) {
+		m.flag(now)
+		return nil
+	}
+
+	return m.syncClock(now)
+}
+
+// flag ends the game on time for the player whose clock ran out.
+func (m *Model) flag(now time.Time) {
+	m.clock.Stop(now)
+	m.flagged = true
+	m.flaggedPlayer = m.clock.turn
+	m.status = m.flaggedPlayer.String() + " ran out of time"
+	m.logResult()
+}
+
+// resetClock gives a new game fresh clocks and forgets a fallen flag.
+func (m *Model) resetClock() {
+	m.flagged = false
+	m.flaggedPlayer = PlayerWhite
+	if m.clock != nil {
+		m.clock = NewClock(m.clock.control)
+	}
+}
+
+// timeoutOutcome returns the result when a player ran out of time: a draw if
+// the opponent could never checkmate, otherwise a win for the opponent.
+func (m *Model) timeoutOutcome() chess.Outcome {
+	winner := m.flaggedPlayer.Switch()
+	if !canCheckmate(m.gameEngine.Position().Board(), winner) {
+		return chess.Draw
+	}
+
+	if winner == PlayerWhite {
+		return chess.WhiteWon
+	}
+	return chess.BlackWon
+}
+
+// canCheckmate reports whether the player could ever deliver checkmate by
+// any series of legal moves, as FIDE article 6.9 asks when a flag falls. A
+// lone king can not, nor can a single knight against a lone king, nor
+// bishops on squares of one color against a king whose other pieces, if
+// any, are bishops on squares of that color too. With help from the
+// opponent's pieces as blockers, anything else can.
+func canCheckmate(b *chess.Board, p Player) bool {
+	knights := 0
+	bishops := map[bool]bool{} // square colors of the player's bishops, true for light
+	for sq, piece := range b.SquareMap() {
+		if playerFromColor(piece.Color()) != p {
+			continue
+		}
+
+		switch piece.Type() {
+		case chess.King:
+		case chess.Knight:
+			knights++
+		case chess.Bishop:
+			bishops[lightSquare(sq)] = true
+		default:
+			return true
+		}
+	}
+
+	switch {
+	case knights > 1, knights == 1 && len(bishops) > 0, len(bishops) > 1:
+		return true
+	case knights == 0 && len(bishops) == 0:
+		return false
+	}
+
+	// a single knight or bishops of one color need an opponent's piece to block
+	// the king, which a bishop on the bishops' square color never does
+	for sq, piece := range b.SquareMap() {
+		if playerFromColor(piece.Color()) == p || piece.Type() == chess.King {
+			continue
+		}
+		if piece.Type() != chess.Bishop || knights == 1 || !bishops[lightSquare(sq)] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// lightSquare reports whether the square is a light one; a1 is dark.
+func lightSquare(sq chess.Square) bool {
+	return (int(sq.File())+int(sq.Rank()))%2 == 1
+}
+
+// outcome returns the result of the game, including a loss on time.
+func (m *Model) outcome() chess.Outcome {
+	if m.flagged {
+		return m.timeoutOutcome()
+	}
+	return m.gameEngine.Outcome()
+}
+
+// formatClock renders the remaining time as m:ss, with tenths in the last ten seconds.
+func formatClock(d time.Duration) string {
+	if d < 10*time.Second {
+		return fmt.Sprintf("0:%04.1f", d.Seconds())
+	}
+
+	d = d.Round(time.Second)
+	return fmt.Sprintf("%d:%02d", int(d.Minutes()), int(d.Seconds())%60)
+}
+
+// renderClocks draws each player's clock at their side of the board, in a
+// column as tall as the board: black's at the top unless the board is flipped.
+func (m *Model) renderClocks(height int) string {
+	now := time.Now()
+	render := func(p Player) string {
+		style := clockStyle
+		if m.clock.running && m.clock.turn == p {
+			style = activeClockStyle
+		}
+		return style.Render(formatClock(m.clock.Remaining(p, now)))
+	}
+
+	top, bottom := render(PlayerBlack), render(PlayerWhite)
+	if m.flipped {
+		top, bottom = bottom, top
+	}
+	gap := max(height-lipgloss.Height(top)-lipgloss.Height(bottom)-2, 0)
+
+	return lipgloss.JoinVertical(lipgloss.Left, "", top, strings.Repeat("\n", gap), bottom)
+}
+
+// timeControlPresets are offered by the start menu.
+var timeControlPresets = []string{"1+0", "3+2", "5+3", "10+5", "15+10", "30+0"}
+
+// SelectTimeControl asks for a time control in the start menu. It returns
+// nil when the game is played without a clock.
+func SelectTimeControl() (*TimeControl, error) {
+	options := []huh.Option[string]{huh.NewOption("No clock", "")}
+	for _, preset := range timeControlPresets {
+		options = append(options, huh.NewOption(preset, preset))
+	}
+
+	var preset string
+	delay := DelayFischer
+	form := huh.NewForm(huh.NewGroup(
+		huh.NewSelect[string]().
+			Title("Time control (minutes + seconds per move)").
+			Options(options...).
+			Value(&preset),
+		huh.NewSelect[DelayMode]().
+			Title("Time added per move").
+			Options(
+				huh.NewOption("Fischer increment", DelayFischer),
+				huh.NewOption("Bronstein delay", DelayBronstein),
+			).
+			Value(&delay),
+	))
+
+	if err := form.Run(); err != nil {
+		return nil, err
+	}
+
+	if preset == "" {
+		return nil, nil
+	}
+
+	tc, err := ParseTimeControl(preset)
+	if err != nil {
+		return nil, err
+	}
+	tc.Delay = delay
+
+	return &tc, nil
+}
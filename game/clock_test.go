@@ -0,0 +1,162 @@
+package game
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/notnil/chess"
+)
+
+func TestParseTimeControl(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    TimeControl
+		wantErr bool
+	}{
+		{in: "5+3", want: TimeControl{Base: 5 * time.Minute, Increment: 3 * time.Second}},
+		{in: "15+10", want: TimeControl{Base: 15 * time.Minute, Increment: 10 * time.Second}},
+		{in: "1", want: TimeControl{Base: time.Minute}},
+		{in: "0.5+1", want: TimeControl{Base: 30 * time.Second, Increment: time.Second}},
+		{in: "0+3", wantErr: true},
+		{in: "5+-1", wantErr: true},
+		{in: "x+3", wantErr: true},
+		{in: "5+x", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseTimeControl(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTimeControl(%q) error = %v, want error %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseTimeControl(%q) = %+v, want %+v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClockPress(t *testing.T) {
+	tests := []struct {
+		name  string
+		delay DelayMode
+		used  time.Duration
+		want  time.Duration // white's time left after the press
+	}{
+		{name: "fischer adds the increment", delay: DelayFischer, used: 10 * time.Second, want: 53 * time.Second},
+		{name: "fischer adds it after a fast move", delay: DelayFischer, used: time.Second, want: 62 * time.Second},
+		{name: "bronstein gives back the time used", delay: DelayBronstein, used: time.Second, want: 60 * time.Second},
+		{name: "bronstein gives back at most the increment", delay: DelayBronstein, used: 10 * time.Second, want: 53 * time.Second},
+	}
+
+	start := time.Now()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewClock(TimeControl{Base: time.Minute, Increment: 3 * time.Second, Delay: tt.delay})
+			c.Start(start)
+			c.Press(start.Add(tt.used))
+
+			if got := c.Remaining(PlayerWhite, start.Add(tt.used)); got != tt.want {
+				t.Errorf("white has %v left, want %v", got, tt.want)
+			}
+			if c.turn != PlayerBlack || !c.running {
+				t.Errorf("black's clock is not running after the press")
+			}
+		})
+	}
+}
+
+func TestClockUndoRedo(t *testing.T) {
+	tc := TimeControl{Base: time.Minute, Increment: 3 * time.Second}
+	m := InitialModel(WithTimeControl(tc))
+	m.syncClock(time.Now())
+
+	m.makeMove("e2e4")
+	m.syncClock(time.Now())
+	m.makeMove("e7e5")
+	m.syncClock(time.Now())
+	want := [2]time.Duration{m.clock.remaining[PlayerWhite], m.clock.remaining[PlayerBlack]}
+
+	for i := 0; i < 10; i++ {
+		m.undo()
+		m.syncClock(time.Now())
+		m.redo()
+		m.syncClock(time.Now())
+	}
+
+	// the clocks keep running, so allow for the time the test takes
+	for _, p := range []Player{PlayerWhite, PlayerBlack} {
+		if got := m.clock.Remaining(p, time.Now()); got > want[p] || got < want[p]-time.Second {
+			t.Errorf("%s has %v left after undo and redo, want %v", p, got, want[p])
+		}
+	}
+}
+
+func TestCanCheckmate(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+		p    Player
+		want bool
+	}{
+		{name: "lone king", fen: "4k3/8/8/8/8/8/8/4K2R w - - 0 1", p: PlayerBlack, want: false},
+		{name: "pawn", fen: "4k3/4p3/8/8/8/8/8/4K3 w - - 0 1", p: PlayerBlack, want: true},
+		{name: "knight against lone king", fen: "4k3/8/8/8/8/8/8/4KN2 w - - 0 1", p: PlayerWhite, want: false},
+		{name: "knight against rook", fen: "r3k3/8/8/8/8/8/8/4KN2 w - - 0 1", p: PlayerWhite, want: true},
+		{name: "bishop against lone king", fen: "4k3/8/8/8/8/8/8/4KB2 w - - 0 1", p: PlayerWhite, want: false},
+		{name: "bishop against knight", fen: "4kn2/8/8/8/8/8/8/4KB2 w - - 0 1", p: PlayerWhite, want: true},
+		{name: "bishop against bishop on the same color", fen: "4kb2/8/8/8/8/8/8/2B1K3 w - - 0 1", p: PlayerWhite, want: false},
+		{name: "bishop against bishop on the other color", fen: "2b1k3/8/8/8/8/8/8/2B1K3 w - - 0 1", p: PlayerWhite, want: true},
+		{name: "two bishops on the same color", fen: "4k3/8/8/8/8/8/8/B1B1K3 w - - 0 1", p: PlayerWhite, want: false},
+		{name: "two bishops on both colors", fen: "4k3/8/8/8/8/8/8/2B1KB2 w - - 0 1", p: PlayerWhite, want: true},
+		{name: "two knights", fen: "4k3/8/8/8/8/8/8/1N2K1N1 w - - 0 1", p: PlayerWhite, want: true},
+		{name: "knight and bishop", fen: "4k3/8/8/8/8/8/8/1N2KB2 w - - 0 1", p: PlayerWhite, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fen, err := chess.FEN(tt.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			board := chess.NewGame(fen).Position().Board()
+			if got := canCheckmate(board, tt.p); got != tt.want {
+				t.Errorf("canCheckmate(%s) = %v, want %v", tt.p, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderClocksFollowFlip(t *testing.T) {
+	m := InitialModel(WithTimeControl(TimeControl{Base: time.Minute}))
+	m.clock.remaining[PlayerBlack] = 30 * time.Second
+
+	for _, flipped := range []bool{false, true} {
+		m.flipped = flipped
+		clocks := m.renderClocks(20)
+		blackOnTop := strings.Index(clocks, "0:30") < strings.Index(clocks, "1:00")
+		if blackOnTop == flipped {
+			t.Errorf("flipped %v: black's clock on top is %v", flipped, blackOnTop)
+		}
+	}
+}
+
+func TestEngineMovePressesClock(t *testing.T) {
+	m := InitialModel(
+		WithAnalyzer(&scriptedAnalyzer{}),
+		WithEngineOpponent(PlayerBlack, EngineSettings{SkillLevel: -1}),
+		WithTimeControl(TimeControl{Base: time.Minute}),
+	)
+	m.syncClock(time.Now())
+
+	msg := m.requestSearch()().(searchResultMsg)
+	m.Update(msg)
+	if len(m.gameEngine.Moves()) != 1 {
+		t.Fatalf("the engine played %d moves, want 1", len(m.gameEngine.Moves()))
+	}
+	if m.clock.turn != PlayerBlack {
+		t.Errorf("%s's clock runs after the engine moved, want black's", m.clock.turn)
+	}
+}
@@ -0,0 +1,103 @@
+package game
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/charmbracelet/huh"
+	"github.com/notnil/chess"
+	"github.com/notnil/chess/uci"
+)
+
+// drawAcceptMargin is the largest advantage, in centipawns from its own point
+// of view, at which the engine still accepts a draw offer.
+const drawAcceptMargin = 20
+
+// engineAcceptsDraw decides on a draw offer made on the human's turn. The
+// score is reported for the side to move, so it is negated to get the
+// engine's point of view.
+func engineAcceptsDraw(score uci.Score) bool {
+	cp, mate := -score.CP, -score.Mate
+
+	switch {
+	case mate > 0:
+		return false
+	case mate < 0:
+		return true
+	default:
+		return cp <= drawAcceptMargin
+	}
+}
+
+// offerDraw offers a draw to the opponent: the engine answers from its
+// evaluation, taking the progress of an infinite search of the position, a
+// human opponent is asked to accept or decline.
+func (m *Model) offerDraw() {
+	if m.outcome() != chess.NoOutcome || m.isEngineTurn() {
+		return
+	}
+
+	var accepted bool
+	if m.mode == ModeEngine {
+		result, ok := m.liveResult()
+		if !ok {
+			m.status = "The engine is still thinking, offer the draw again in a moment"
+			return
+		}
+		if result.BestMove == nil {
+			m.status = "The engine can't judge the position, so it can't answer the draw offer"
+			return
+		}
+		accepted = engineAcceptsDraw(result.Info.Score)
+	} else {
+		opponent := m.currentPlayer.Switch()
+		form := huh.NewConfirm().
+			Title(fmt.Sprintf("%s offers a draw. Does %s accept?", m.currentPlayer, opponent)).
+			Affirmative("Accept").
+			Negative("Decline").
+			Value(&accepted)
+
+		if err := form.Run(); err != nil {
+			slog.Error("input error", "err", err)
+			return
+		}
+	}
+
+	if !accepted {
+		m.status = "Draw offer declined"
+		return
+	}
+
+	if err := m.gameEngine.Draw(chess.DrawOffer); err != nil {
+		slog.Error("error drawing game", "err", err)
+		return
+	}
+
+	m.status = "Draw offer accepted"
+	m.logResult()
+}
+
+// claimDraw ends the game by threefold repetition or the fifty-move rule when
+// the position allows it.
+func (m *Model) claimDraw() {
+	if m.outcome() != chess.NoOutcome || m.isEngineTurn() {
+		return
+	}
+
+	for _, method := range m.gameEngine.EligibleDraws() {
+		if method == chess.DrawOffer {
+			continue
+		}
+
+		if err := m.gameEngine.Draw(method); err != nil {
+			slog.Error("error claiming draw", "method", method, "err", err)
+			return
+		}
+
+		m.status = "Draw claimed by " + terminationReasons[method]
+		m.logResult()
+		return
+	}
+
+	m.status = "No draw can be claimed, it needs a threefold repetition or fifty moves without a capture or pawn move"
+}
@@ -0,0 +1,232 @@
+package game
+
+import (
+	"log/slog"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/notnil/chess"
+	"github.com/notnil/chess/uci"
+)
+
+// searchMoveTime is how long the engine is allowed to think when it only suggests a move.
+const searchMoveTime = time.Second / 100
+
+// searchResultMsg is sent once the engine has finished searching a position.
+type searchResultMsg struct {
+	fen    string
+	result Analysis
+	err    error
+}
+
+// searchPosition runs the engine search for the given position outside the UI loop.
+func searchPosition(a Analyzer, pos *chess.Position, cmdGo uci.CmdGo) tea.Cmd {
+	fen := pos.String()
+
+	return func() tea.Msg {
+		result, err := a.Search(pos, cmdGo)
+		return searchResultMsg{fen: fen, result: result, err: err}
+	}
+}
+
+// stopSearch asks the engine to abandon the search that is currently running.
+func stopSearch(a Analyzer) tea.Cmd {
+	return func() tea.Msg {
+		if err := a.Stop(); err != nil {
+			slog.Error("error stopping engine search", "err", err)
+		}
+		return nil
+	}
+}
+
+// requestSearch starts a search for the current position unless its result is
+// already cached. Only one search runs at a time; if the position changed while
+// the engine was busy, the running search is stopped and the next one starts
+// once its result has come back. When the engine is to move and it has chosen
+// its move in the position before, the move is played right away; results of
+// the quicker searches for suggestions and analysis are never played. With
+// infinite analysis on, the human's positions are searched until the position
+// changes or analysis is turned off. A review of the game takes the engine
+// until it is done.
+func (m *Model) requestSearch() tea.Cmd {
+	if !m.hasEngine() || m.settingLines {
+		return nil
+	}
+
+	if m.reviewing() {
+		return m.requestReviewSearch()
+	}
+
+	pos := m.gameEngine.Position()
+	fen := pos.String()
+	over := m.outcome() != chess.NoOutcome
+	infinite := m.infinite && !over && !m.isEngineTurn()
+
+	result, cached := m.searchResults[fen]
+	move, chosen := m.engineMoves[fen]
+	if chosen && !over && m.isEngineTurn() && move != nil {
+		m.playMove(move)
+		return m.requestSearch()
+	}
+
+	if m.searchingFEN != "" {
+		stale := m.searchingFEN != fen || (m.searchInfinite && !infinite)
+		if !stale || m.searchStopped {
+			return nil
+		}
+		m.searchStopped = true
+		return stopSearch(m.analyzer)
+	}
+
+	if over || (m.isEngineTurn() && chosen) {
+		return nil
+	}
+
+	// an infinite search goes deeper than the cached one, unless that failed
+	if !m.isEngineTurn() && cached && (!infinite || result.BestMove == nil) {
+		return nil
+	}
+
+	cmdGo := uci.CmdGo{MoveTime: searchMoveTime}
+	if m.isEngineTurn() {
+		cmdGo = m.engineSettings.goCmd()
+	} else if infinite {
+		cmdGo = uci.CmdGo{Infinite: true}
+	}
+
+	m.searchingFEN = fen
+	m.searchStopped = false
+	m.searchInfinite = cmdGo.Infinite
+	m.searchingMove = m.isEngineTurn()
+	return searchPosition(m.analyzer, pos, cmdGo)
+}
+
+// handleSearchResult caches a finished search and schedules the next one.
+func (m *Model) handleSearchResult(msg searchResultMsg) tea.Cmd {
+	if m.searchingMove && !m.searchStopped {
+		// a failed search is recorded too, so a failing engine is not queried in a loop
+		m.engineMoves[msg.fen] = msg.result.BestMove
+	}
+
+	m.searchingFEN = ""
+	m.searchingMove = false
+	m.searchStopped = false
+	m.searchInfinite = false
+	m.progress, m.progressFEN = Analysis{}, ""
+
+	if msg.err != nil {
+		slog.Error("error from chess engine",
+			"fen", msg.fen,
+			"err", msg.err,
+		)
+		// cache the empty result so a failing engine is not queried in a loop
+		m.searchResults[msg.fen] = Analysis{}
+		if m.review != nil && m.review.searching {
+			m.handleReviewResult(msg)
+		}
+		return nil
+	}
+
+	m.searchResults[msg.fen] = msg.result
+	if m.review != nil && m.review.searching {
+		m.handleReviewResult(msg)
+	}
+
+	return m.requestSearch()
+}
+
+// analysisTickInterval is how often the lines of an infinite search are read.
+const analysisTickInterval = 300 * time.Millisecond
+
+// analysisTickMsg reads the lines found so far by an infinite search.
+type analysisTickMsg struct{}
+
+func analysisTick() tea.Cmd {
+	return tea.Tick(analysisTickInterval, func(time.Time) tea.Msg {
+		return analysisTickMsg{}
+	})
+}
+
+// toggleInfinite turns infinite analysis of the human's positions on or off.
+func (m *Model) toggleInfinite() {
+	if !m.hasEngine() {
+		m.status = "Attach an engine with 'E' to analyse"
+		return
+	}
+
+	m.infinite = !m.infinite
+	if m.infinite {
+		m.status = "Infinite analysis on"
+	} else {
+		m.status = "Infinite analysis off"
+	}
+}
+
+// syncAnalysis keeps the progress of an infinite search flowing while
+// infinite analysis is on. It returns the tick command when one is due.
+func (m *Model) syncAnalysis() tea.Cmd {
+	if !m.infinite || m.analysisTicking {
+		return nil
+	}
+
+	m.analysisTicking = true
+	return analysisTick()
+}
+
+// handleAnalysisTick shows the lines the running infinite search has found so far.
+func (m *Model) handleAnalysisTick() tea.Cmd {
+	m.analysisTicking = false
+	if m.searchInfinite && !m.searchStopped {
+		m.readProgress()
+	}
+
+	return m.syncAnalysis()
+}
+
+// readProgress takes the lines of the running search, skipping lines left
+// over from the previous search that do not fit the searched position.
+func (m *Model) readProgress() {
+	lines := m.analyzer.Progress()
+	if len(lines) == 0 {
+		return
+	}
+
+	pos, err := decodeFEN(m.searchingFEN)
+	if err != nil {
+		return
+	}
+	if moves, _ := resolveLine(pos, lines[0].PV, 1); len(moves) == 0 {
+		return
+	}
+
+	m.progress = Analysis{
+		SearchResults: uci.SearchResults{BestMove: lines[0].PV[0], Info: lines[0]},
+		Lines:         lines,
+	}
+	m.progressFEN = m.searchingFEN
+}
+
+// stopInfinite ends a running infinite search right away, for commands that
+// need the engine, which it holds until it is stopped.
+func (m *Model) stopInfinite() {
+	if !m.searchInfinite || m.searchStopped {
+		return
+	}
+
+	if err := m.analyzer.Stop(); err != nil {
+		slog.Error("error stopping engine search", "err", err)
+	}
+	m.searchStopped = true
+}
+
+// liveResult returns the engine result for the game position, preferring the
+// progress of an infinite search of it.
+func (m *Model) liveResult() (Analysis, bool) {
+	fen := m.gameEngine.Position().String()
+	if m.progressFEN == fen {
+		return m.progress, true
+	}
+
+	result, ok := m.searchResults[fen]
+	return result, ok
+}
@@ -0,0 +1,104 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/notnil/chess"
+	"github.com/notnil/chess/uci"
+)
+
+// scriptedAnalyzer answers every search with the first legal move and
+// records the searches and options it was asked for.
+type scriptedAnalyzer struct {
+	NoAnalyzer
+	searches []uci.CmdGo
+	options  []uci.Cmd
+}
+
+func (a *scriptedAnalyzer) SetOptions(options ...uci.Cmd) error {
+	a.options = append(a.options, options...)
+	return nil
+}
+
+func (a *scriptedAnalyzer) Search(pos *chess.Position, cmdGo uci.CmdGo) (Analysis, error) {
+	a.searches = append(a.searches, cmdGo)
+	return Analysis{SearchResults: uci.SearchResults{BestMove: pos.ValidMoves()[0]}}, nil
+}
+
+// runSearches feeds the results of the requested searches back to the model.
+func runSearches(m *Model) {
+	for cmd := m.requestSearch(); cmd != nil; {
+		msg, ok := cmd().(searchResultMsg)
+		if !ok {
+			return
+		}
+		cmd = m.handleSearchResult(msg)
+	}
+}
+
+func TestEngineSearchesItsOwnMove(t *testing.T) {
+	a := &scriptedAnalyzer{}
+	settings := EngineSettings{SkillLevel: -1, Depth: 12}
+	m := InitialModel(WithAnalyzer(a), WithEngineOpponent(PlayerWhite, settings))
+
+	// the human plays white: the starting position is only searched for a suggestion
+	runSearches(m)
+	if len(m.gameEngine.Moves()) != 0 {
+		t.Fatalf("the engine moved for the human")
+	}
+
+	// after the rematch the engine plays white and searches for its move
+	m.rematch()
+	runSearches(m)
+	if len(m.gameEngine.Moves()) != 1 {
+		t.Fatalf("the engine played %d moves, want 1", len(m.gameEngine.Moves()))
+	}
+	if last := a.searches[len(a.searches)-2]; last.Depth != settings.Depth {
+		t.Errorf("the engine played a move from %+v, want a search to depth %d", last, settings.Depth)
+	}
+}
+
+func TestDrawOfferAfterFailedSearch(t *testing.T) {
+	m := InitialModel(WithAnalyzer(&scriptedAnalyzer{}), WithEngineOpponent(PlayerWhite, EngineSettings{SkillLevel: -1}))
+
+	// a failed search caches an empty result
+	m.handleSearchResult(searchResultMsg{fen: m.gameEngine.Position().String(), err: ErrNoEngine})
+	m.offerDraw()
+	if m.outcome() != chess.NoOutcome {
+		t.Fatalf("the engine accepted a draw without an evaluation: %s", m.status)
+	}
+}
+
+func TestDrawOfferDuringInfiniteSearch(t *testing.T) {
+	m := InitialModel(WithAnalyzer(&scriptedAnalyzer{}), WithEngineOpponent(PlayerWhite, EngineSettings{SkillLevel: -1}))
+
+	// the infinite search of the human's position has found a level line
+	pos := m.gameEngine.Position()
+	m.progress = Analysis{SearchResults: uci.SearchResults{BestMove: pos.ValidMoves()[0]}}
+	m.progressFEN = pos.String()
+
+	m.offerDraw()
+	if m.outcome() != chess.Draw {
+		t.Fatalf("the engine did not answer the draw offer: %s", m.status)
+	}
+}
+
+func TestLinesPanelKeepsConfiguredMultiPV(t *testing.T) {
+	a := &scriptedAnalyzer{}
+	m := InitialModel(WithAnalyzer(a), WithMultiPV(5))
+	if m.multiPV != 5 {
+		t.Fatalf("the lines panel shows %d lines, want the 5 configured", m.multiPV)
+	}
+
+	for _, want := range []int{0, 5} {
+		m.handleLinesSet(m.toggleLines()().(linesSetMsg))
+		if m.multiPV != want {
+			t.Errorf("the lines panel shows %d lines, want %d", m.multiPV, want)
+		}
+
+		option := a.options[len(a.options)-1].(uci.CmdSetOption)
+		if option.Name != "MultiPV" || option.Value != "5" {
+			t.Errorf("sent %s %s to the engine, want MultiPV 5", option.Name, option.Value)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package game
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/notnil/chess"
+	"github.com/notnil/chess/uci"
+)
+
+// evalBarWidth is the width of the eval bar column, wide enough for a score like "+12.34".
+const evalBarWidth = 6
+
+// winChanceScale turns centipawns into a winning chance, as on lichess.org.
+const winChanceScale = 0.00368208
+
+// whiteScore converts a score reported for the side to move in pos to white's point of view.
+func whiteScore(pos *chess.Position, score uci.Score) uci.Score {
+	if pos.Turn() == chess.Black {
+		score.CP, score.Mate = -score.CP, -score.Mate
+	}
+
+	return score
+}
+
+// formatScore renders a score from white's point of view, e.g. "+0.35", "-1.20" or "#-3".
+func formatScore(score uci.Score) string {
+	if score.Mate != 0 {
+		return fmt.Sprintf("#%d", score.Mate)
+	}
+
+	return fmt.Sprintf("%+.2f", float64(score.CP)/100)
+}
+
+// whiteShare returns how much of the eval bar belongs to white, from 0 to 1.
+func whiteShare(score uci.Score) float64 {
+	switch {
+	case score.Mate > 0:
+		return 1
+	case score.Mate < 0:
+		return 0
+	default:
+		return 1 / (1 + math.Exp(-winChanceScale*float64(score.CP)))
+	}
+}
+
+// shownResult returns the engine result for the game position on the board,
+// if it was searched, or the progress of an infinite search of it. A previewed
+// line keeps the result it came from.
+func (m *Model) shownResult() (Analysis, bool) {
+	fen := m.gamePosition().String()
+	if m.progressFEN == fen {
+		return m.progress, true
+	}
+
+	result, ok := m.searchResults[fen]
+	if !ok || result.BestMove == nil {
+		return Analysis{}, false
+	}
+
+	return result, true
+}
+
+// renderEvalBar draws the score above a bar as tall as the squares, white's
+// share at white's side of the board.
+func (m *Model) renderEvalBar(l layout) string {
+	height := boardSize * l.height
+	share, readout := 0.5, "?"
+	if result, ok := m.shownResult(); ok {
+		score := whiteScore(m.gamePosition(), result.Info.Score)
+		share, readout = whiteShare(score), formatScore(score)
+	}
+
+	white := int(math.Round(share * float64(height)))
+	whiteCells := strings.Repeat(evalWhiteStyle.Render("  ")+"\n", white)
+	blackCells := strings.Repeat(evalBlackStyle.Render("  ")+"\n", height-white)
+
+	bar := blackCells + whiteCells
+	if m.flipped {
+		bar = whiteCells + blackCells
+	}
+
+	return lipgloss.NewStyle().Width(evalBarWidth).Align(lipgloss.Center).Render(
+		labelStyle.Render(readout) + "\n" + strings.TrimSuffix(bar, "\n"),
+	)
+}
+
+// toggleAnalysis shows or hides the analysis panel.
+func (m *Model) toggleAnalysis() {
+	m.showAnalysis = !m.showAnalysis
+}
+
+// renderAnalysis draws the search statistics of the engine for the position on the board.
+func (m *Model) renderAnalysis() string {
+	lines := []string{analysisTitleStyle.Render("Analysis")}
+	if result, ok := m.shownResult(); ok {
+		info := result.Info
+		lines = append(lines,
+			fmt.Sprintf("Score  %s", formatScore(whiteScore(m.gamePosition(), info.Score))),
+			fmt.Sprintf("Depth  %d/%d", info.Depth, info.Seldepth),
+			fmt.Sprintf("Nodes  %s", formatCount(info.Nodes)),
+			fmt.Sprintf("NPS    %s", formatCount(info.NPS)),
+			fmt.Sprintf("Time   %s", info.Time.Round(time.Millisecond)),
+		)
+	} else {
+		lines = append(lines, "not searched yet")
+	}
+
+	return analysisStyle.Render(strings.Join(lines, "\n"))
+}
+
+// formatCount shortens large counts, e.g. 1234567 to "1.2M".
+func formatCount(n int) string {
+	switch {
+	case n >= 1_000_000_000:
+		return fmt.Sprintf("%.1fG", float64(n)/1e9)
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1e6)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fk", float64(n)/1e3)
+	default:
+		return fmt.Sprint(n)
+	}
+}
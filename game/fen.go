@@ -0,0 +1,112 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/notnil/chess"
+)
+
+// normalizeFEN trims the input and adds the move counters when only the
+// first four FEN fields (board, turn, castling, en passant) are given.
+func normalizeFEN(fen string) string {
+	fields := strings.Fields(fen)
+	if len(fields) == 4 {
+		fields = append(fields, "0", "1")
+	}
+
+	return strings.Join(fields, " ")
+}
+
+// ValidateFEN reports why fen does not describe a playable position.
+func ValidateFEN(fen string) error {
+	fen = normalizeFEN(fen)
+	if fen == "" {
+		return errors.New("FEN must not be empty")
+	}
+
+	pos, err := decodeFEN(fen)
+	if err != nil {
+		return err
+	}
+
+	var whiteKings, blackKings int
+	for sq, p := range pos.Board().SquareMap() {
+		switch p {
+		case chess.WhiteKing:
+			whiteKings++
+		case chess.BlackKing:
+			blackKings++
+		case chess.WhitePawn, chess.BlackPawn:
+			if sq.Rank() == chess.Rank1 || sq.Rank() == chess.Rank8 {
+				return fmt.Errorf("pawn on %s can not stand on the first or last rank", sq)
+			}
+		}
+	}
+
+	if whiteKings != 1 || blackKings != 1 {
+		return fmt.Errorf("each side needs exactly one king, found %d white and %d black", whiteKings, blackKings)
+	}
+
+	// the side that just moved can not have left its own king in check
+	fields := strings.Fields(fen)
+	fields[1] = map[string]string{"w": "b", "b": "w"}[fields[1]]
+	if opponent, err := decodeFEN(strings.Join(fields, " ")); err == nil && chess.IsInCheck(opponent) {
+		return fmt.Errorf("%s is in check but it is %s's turn", pos.Turn().Other().Name(), pos.Turn().Name())
+	}
+
+	return nil
+}
+
+// decodeFEN parses fen into a position, with the error message stripped of the library prefix.
+func decodeFEN(fen string) (*chess.Position, error) {
+	pos := &chess.Position{}
+	if err := pos.UnmarshalText([]byte(fen)); err != nil {
+		return nil, errors.New(strings.TrimPrefix(err.Error(), "chess: "))
+	}
+
+	return pos, nil
+}
+
+// SetPosition starts a new game from the position described by fen.
+func (m *Model) SetPosition(fen string) error {
+	if err := ValidateFEN(fen); err != nil {
+		return err
+	}
+
+	if m.replay != nil {
+		m.stopReplay()
+	}
+
+	// a new position is a new game with fresh clocks and no review
+	m.resetClock()
+	m.review = nil
+
+	return m.resetGame(normalizeFEN(fen))
+}
+
+// handleFENInput prompts for a FEN and sets up the board from it.
+func (m *Model) handleFENInput() {
+	var fen string
+	form := huh.NewInput().
+		Title("Set position from FEN:").
+		Placeholder(chess.StartingPosition().String()).
+		Validate(ValidateFEN).
+		Value(&fen)
+
+	if err := form.Run(); err != nil {
+		slog.Error("input error", "err", err)
+		return
+	}
+
+	if err := m.SetPosition(fen); err != nil {
+		slog.Error("error setting position", "fen", fen, "err", err)
+		m.status = "Invalid FEN: " + err.Error()
+		return
+	}
+
+	m.status = "Position set from FEN"
+}
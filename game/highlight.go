@@ -0,0 +1,140 @@
+package game
+
+import (
+	"github.com/charmbracelet/lipgloss"
+	"github.com/notnil/chess"
+)
+
+// lastMoveSquares returns the from and to squares of the move that led to
+// the shown position. It reports false before the first move.
+func (m *Model) lastMoveSquares() (from, to square, ok bool) {
+	var move *chess.Move
+	if m.preview != nil && m.preview.ply > 0 {
+		move = m.preview.moves[m.preview.ply-1]
+	} else if ply := m.shownPly(); ply > 0 {
+		move = m.gameEngine.Moves()[ply-1]
+	} else {
+		return square{}, square{}, false
+	}
+
+	fromX, fromY := coordinates(move.S1().String())
+	toX, toY := coordinates(move.S2().String())
+
+	return square{fromX, fromY}, square{toX, toY}, true
+}
+
+// checkedKing returns the square of the king in check in the shown position.
+// It reports false when the side to move is not in check.
+func (m *Model) checkedKing() (square, bool) {
+	pos := m.shownPosition()
+	if !chess.IsInCheck(pos) {
+		return square{}, false
+	}
+
+	king := chess.NewPiece(chess.King, pos.Turn())
+	for sq, p := range pos.Board().SquareMap() {
+		if p == king {
+			x, y := coordinates(sq.String())
+			return square{x, y}, true
+		}
+	}
+
+	return square{}, false
+}
+
+// highlight is what a square of the board is highlighted for, if anything.
+type highlight int
+
+const (
+	highlightNone highlight = iota
+	highlightRejected
+	highlightSelected
+	highlightCursor
+	highlightCheck
+	highlightHint
+	highlightLastMove
+)
+
+// highlightMarks are the glyphs drawn left and right of the piece on a
+// highlighted square when the theme marks highlights.
+var highlightMarks = [...][2]string{
+	highlightRejected: {"×", "×"},
+	highlightSelected: {"[", "]"},
+	highlightCursor:   {"›", "‹"},
+	highlightCheck:    {"!", "!"},
+	highlightHint:     {"+", "+"},
+	highlightLastMove: {"-", "-"},
+}
+
+// highlights returns the highlight of every square of the shown position,
+// by row and column. The rejected move, the cursor and the check come before
+// the hint and the last move when they fall on the same square.
+func (m *Model) highlights() [boardSize][boardSize]highlight {
+	king, inCheck := m.checkedKing()
+	hint, hasHint := m.bestMove()
+	lastFrom, lastTo, hasLastMove := m.lastMoveSquares()
+
+	var squares [boardSize][boardSize]highlight
+	for x := range squares {
+		for y := range squares[x] {
+			sq := square{x, y}
+			cursor := m.cursorX == y && m.cursorY == x
+
+			switch {
+			case m.rejected && m.rejectedX == y && m.rejectedY == x:
+				squares[x][y] = highlightRejected
+			case cursor && m.selected:
+				squares[x][y] = highlightSelected
+			case cursor:
+				squares[x][y] = highlightCursor
+			case inCheck && sq == king:
+				squares[x][y] = highlightCheck
+			case hasHint && (sq == hint.from || sq == hint.to):
+				squares[x][y] = highlightHint
+			case hasLastMove && (sq == lastFrom || sq == lastTo):
+				squares[x][y] = highlightLastMove
+			}
+		}
+	}
+
+	return squares
+}
+
+// style returns the style of a light or dark square with the highlight.
+func (h highlight) style(dark bool) lipgloss.Style {
+	switch {
+	case h == highlightRejected:
+		return rejectedStyle
+	case h == highlightSelected:
+		return selectedStyle
+	case h == highlightCursor && dark:
+		return blackCursorStyle
+	case h == highlightCursor:
+		return whiteCursorStyle
+	case h == highlightCheck:
+		return checkStyle
+	case h == highlightHint && dark:
+		return blackHintStyle
+	case h == highlightHint:
+		return whiteHintStyle
+	case h == highlightLastMove && dark:
+		return blackLastMoveStyle
+	case h == highlightLastMove:
+		return whiteLastMoveStyle
+	case dark:
+		return blackSquare
+	default:
+		return whiteSquare
+	}
+}
+
+// marked reports whether the highlight is drawn with its marks.
+func (h highlight) marked() bool {
+	return markHighlights && h != highlightNone
+}
+
+// mark draws the marks of the highlight around the rendered square.
+func (h highlight) mark(cell string) string {
+	marks := highlightMarks[h]
+	return markStyle.Render(marks[0]) + cell + markStyle.Render(marks[1])
+}
@@ -0,0 +1,75 @@
+package game
+
+import (
+	"github.com/notnil/chess"
+)
+
+// bestMoveHint is the move the engine suggests, drawn on the board.
+type bestMoveHint struct {
+	from, to square
+	path     []square // squares a sliding piece passes between from and to
+}
+
+// bestMove returns the engine's suggested move for the shown game position.
+// It reports false while hints are off, while a line is previewed or before
+// the position was searched.
+func (m *Model) bestMove() (bestMoveHint, bool) {
+	if !m.showHints || m.preview != nil {
+		return bestMoveHint{}, false
+	}
+
+	result, ok := m.shownResult()
+	if !ok {
+		return bestMoveHint{}, false
+	}
+
+	fromX, fromY := coordinates(result.BestMove.S1().String())
+	toX, toY := coordinates(result.BestMove.S2().String())
+	hint := bestMoveHint{from: square{fromX, fromY}, to: square{toX, toY}}
+
+	switch m.gamePosition().Board().Piece(result.BestMove.S1()).Type() {
+	case chess.Queen, chess.Rook, chess.Bishop:
+		hint.path = pathBetween(hint.from, hint.to)
+	}
+
+	return hint, true
+}
+
+// pathBetween returns the squares strictly between two squares on the same
+// rank, file or diagonal, in the order a piece passes them.
+func pathBetween(from, to square) []square {
+	dx, dy := sign(to[0]-from[0]), sign(to[1]-from[1])
+
+	var path []square
+	for sq := (square{from[0] + dx, from[1] + dy}); sq != to; sq = (square{sq[0] + dx, sq[1] + dy}) {
+		path = append(path, sq)
+	}
+
+	return path
+}
+
+func sign(n int) int {
+	switch {
+	case n > 0:
+		return 1
+	case n < 0:
+		return -1
+	default:
+		return 0
+	}
+}
+
+// toggleHints shows or hides the engine's best move on the board.
+func (m *Model) toggleHints() {
+	if !m.hasEngine() {
+		m.status = "Attach an engine with 'E' to see its hints"
+		return
+	}
+
+	m.showHints = !m.showHints
+	if m.showHints {
+		m.status = "Best move hints on"
+	} else {
+		m.status = "Best move hints off"
+	}
+}
@@ -0,0 +1,179 @@
+package game
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/notnil/chess"
+)
+
+// shownPly returns the number of moves played in the position on the board.
+func (m *Model) shownPly() int {
+	if m.viewing {
+		return m.viewPly
+	}
+	return len(m.gameEngine.Moves())
+}
+
+// gamePosition returns the position of the game on the board, which is a
+// past one while browsing the history.
+func (m *Model) gamePosition() *chess.Position {
+	return m.gameEngine.Positions()[m.shownPly()]
+}
+
+// shownPosition returns the position on the board, including a previewed engine line.
+func (m *Model) shownPosition() *chess.Position {
+	if m.preview != nil {
+		return m.preview.positions[m.preview.ply]
+	}
+	return m.gamePosition()
+}
+
+// displayBoard returns the board to draw, which is a past position while
+// browsing the history or a line of the engine while previewing it.
+func (m *Model) displayBoard() *Board {
+	if m.viewing || m.preview != nil {
+		return NewBoardFromPosition(m.shownPosition())
+	}
+	return m.board
+}
+
+func (m *Model) historyBack() {
+	m.stopPreview()
+	if ply := m.shownPly() - 1; ply >= 0 {
+		m.viewing = true
+		m.viewPly = ply
+	}
+}
+
+func (m *Model) historyForward() {
+	m.stopPreview()
+	if !m.viewing {
+		return
+	}
+
+	m.viewPly++
+	if m.viewPly >= len(m.gameEngine.Moves()) {
+		m.showLive()
+	}
+}
+
+// showLive returns from browsing the history to the current position.
+func (m *Model) showLive() {
+	m.viewing = false
+	m.viewPly = 0
+}
+
+// handleHistoryKey browses earlier positions and reports whether the key was handled.
+func (m *Model) handleHistoryKey(key string) bool {
+	switch key {
+	case "shift+left", "[":
+		m.historyBack()
+	case "shift+right", "]":
+		m.historyForward()
+	case "esc":
+		if !m.viewing {
+			return false
+		}
+		m.showLive()
+	default:
+		return false
+	}
+
+	return true
+}
+
+// branchFromHistory asks whether to continue the game from the position being
+// viewed, discarding the later moves. It reports whether play can go on.
+func (m *Model) branchFromHistory() bool {
+	moves := m.gameEngine.Moves()
+	discarded := len(moves) - m.viewPly
+
+	var confirmed bool
+	form := huh.NewConfirm().
+		Title(fmt.Sprintf("Continue from this position? The last %d moves will be discarded.", discarded)).
+		Affirmative("Branch").
+		Negative("Cancel").
+		Value(&confirmed)
+
+	if err := form.Run(); err != nil {
+		slog.Error("input error", "err", err)
+		return false
+	}
+
+	if !confirmed {
+		return false
+	}
+
+	if m.replay != nil {
+		m.stopReplay()
+	}
+
+	if err := m.rebuildGame(m.gameEngine.Positions()[0].String(), moves[:m.viewPly]); err != nil {
+		slog.Error("error branching game", "err", err)
+		return false
+	}
+
+	return true
+}
+
+// formatHistory renders the moves like "1. e4 e5\n2. Nf3 Nc6", highlighting
+// the move at index highlight (none when negative).
+func (m *Model) formatHistory(highlight int) string {
+	moveNumber, blackToMove := startingMove(m.gameEngine.Positions()[0])
+
+	var sb strings.Builder
+	for i, text := range m.historyMoves {
+		text = m.annotateMove(i, text)
+		if i == highlight {
+			text = currentMoveStyle.Render(text)
+		}
+
+		if !blackToMove {
+			// Start a new line with the move number
+			fmt.Fprintf(&sb, "\n%d. %s", moveNumber, text)
+		} else if i == 0 {
+			// The game started with black to move
+			fmt.Fprintf(&sb, "\n%d... %s", moveNumber, text)
+		} else {
+			// Append black's move to the existing line
+			fmt.Fprintf(&sb, " %s", text)
+		}
+
+		if blackToMove {
+			moveNumber++
+		}
+		blackToMove = !blackToMove
+	}
+
+	return sb.String()
+}
+
+// historyWindow renders the moves like formatHistory in at most height lines,
+// scrolled so that the move at index highlight is shown, or the first line
+// when highlight is negative.
+func (m *Model) historyWindow(highlight, height int) string {
+	text := m.formatHistory(highlight)
+	lines := strings.Split(text, "\n")
+	if len(lines) <= height {
+		return text
+	}
+	if height <= 0 {
+		return ""
+	}
+
+	// the first line is empty and every line holds a white and a black move
+	focus := 0
+	if highlight >= 0 {
+		_, blackToMove := startingMove(m.gameEngine.Positions()[0])
+		if blackToMove {
+			highlight++
+		}
+		focus = 1 + highlight/2
+	}
+
+	end := min(max(focus+1, height), len(lines))
+	return strings.Join(lines[end-height:end], "\n")
+}
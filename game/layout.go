@@ -0,0 +1,120 @@
+package game
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	rankLabelWidth = 2  // width of the rank labels left of the board, e.g. " 8"
+	boardBorder    = 1  // hidden table border around the squares
+	fileLabelLines = 2  // blank line plus the file labels below the board
+	sidePanelWidth = 30 // room kept right of the board for the clocks and the moves
+)
+
+// squareSize is the size of a board square in terminal cells.
+type squareSize struct {
+	width, height int
+}
+
+// squareSizes are the sizes the board can be drawn with, from compact to large.
+var squareSizes = []squareSize{
+	{width: 3, height: 1},
+	{width: 5, height: 2},
+	{width: 7, height: 3},
+	{width: 9, height: 4},
+}
+
+// defaultSquareSize is used until the terminal reports its size.
+var defaultSquareSize = squareSizes[2]
+
+// padding returns the blank cells around the piece glyph, which is one cell wide.
+func (s squareSize) padding() (top, right, bottom, left int) {
+	top = (s.height - 1) / 2
+	left = (s.width - 1) / 2
+	return top, s.width - 1 - left, s.height - 1 - top, left
+}
+
+// layout is where the board is drawn on screen and how big its squares are.
+// Rendering and mouse hit-testing both derive from it, so clicks land on the
+// square that is drawn under the pointer.
+type layout struct {
+	squareSize
+	left, top int // screen cell of the top-left corner of the first square
+}
+
+// layout fits the largest square size into the terminal next to the header,
+// the status lines and the side panel.
+func (m *Model) layout() layout {
+	header := m.renderHeader()
+	size := defaultSquareSize
+	if m.width > 0 && m.height > 0 {
+		chrome := lipgloss.Height(header) + 2*boardBorder + fileLabelLines + lipgloss.Height(m.renderStatus())
+		size = squareSizes[0]
+		for _, s := range squareSizes[1:] {
+			fitsWidth := rankLabelWidth+2*boardBorder+boardSize*s.width+evalBarWidth+sidePanelWidth <= m.width
+			fitsHeight := boardSize*s.height+chrome <= m.height
+			if fitsWidth && fitsHeight {
+				size = s
+			}
+		}
+	}
+
+	return layout{
+		squareSize: size,
+		left:       rankLabelWidth + boardBorder,
+		top:        strings.Count(header, "\n") + boardBorder,
+	}
+}
+
+// squareStyle sizes a square style, leaving room for the ring around a capture.
+func (l layout) squareStyle(style lipgloss.Style, ring bool) lipgloss.Style {
+	top, right, bottom, left := l.padding()
+	if ring {
+		right, left = max(right-1, 0), max(left-1, 0)
+	}
+
+	return style.Padding(top, right, bottom, left)
+}
+
+// squareAt returns the square drawn at the screen cell, as row and column on screen.
+func (l layout) squareAt(x, y int) (row, col int, ok bool) {
+	if x < l.left || y < l.top {
+		return 0, 0, false
+	}
+
+	row, col = (y-l.top)/l.height, (x-l.left)/l.width
+	return row, col, row < boardSize && col < boardSize
+}
+
+// rankLabels renders the rank labels next to the middle line of each row.
+func (l layout) rankLabels(ranks []string) string {
+	top, _, _, _ := l.padding()
+	lines := []string{""}
+	for _, rank := range ranks {
+		for i := 0; i < l.height; i++ {
+			if i == top {
+				lines = append(lines, labelStyle.Render(" "+rank))
+			} else {
+				lines = append(lines, "")
+			}
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// fileLabels renders the file labels below the middle column of each square.
+func (l layout) fileLabels(files []string) string {
+	_, _, _, left := l.padding()
+	return labelStyle.Render(
+		"\n" + strings.Repeat(" ", l.left+left) + strings.Join(files, strings.Repeat(" ", l.width-1)),
+	)
+}
+
+// clipLines cuts text to its first height lines.
+func clipLines(text string, height int) string {
+	lines := strings.Split(text, "\n")
+	return strings.Join(lines[:min(len(lines), max(height, 0))], "\n")
+}
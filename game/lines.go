@@ -0,0 +1,216 @@
+package game
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/notnil/chess"
+	"github.com/notnil/chess/uci"
+)
+
+const (
+	defaultMultiPV = 3  // lines shown when the lines panel is opened, unless configured
+	lineLength     = 10 // moves of a line shown in the panel and previewed on the board
+)
+
+// linePreview steps through a line of the engine on the board without playing it.
+type linePreview struct {
+	rank      int               // rank of the line, 1 for the best
+	moves     []*chess.Move     // moves of the line
+	positions []*chess.Position // positions[0] is where the line starts
+	ply       int               // moves of the line shown on the board
+}
+
+// resolveLine replays up to limit moves of a principal variation from pos. The
+// engine reports the moves without tags, so each is matched against the legal
+// moves to get captures, checks and castling right for SAN.
+func resolveLine(pos *chess.Position, pv []*chess.Move, limit int) ([]*chess.Move, []*chess.Position) {
+	positions := []*chess.Position{pos}
+	var moves []*chess.Move
+	for _, mv := range pv[:min(len(pv), limit)] {
+		var legal *chess.Move
+		for _, v := range pos.ValidMoves() {
+			if v.S1() == mv.S1() && v.S2() == mv.S2() && v.Promo() == mv.Promo() {
+				legal = v
+				break
+			}
+		}
+		if legal == nil {
+			break
+		}
+
+		pos = pos.Update(legal)
+		moves = append(moves, legal)
+		positions = append(positions, pos)
+	}
+
+	return moves, positions
+}
+
+// formatLine renders moves played from the first position in SAN with move
+// numbers, e.g. "12... Nf6 13. e5".
+func formatLine(positions []*chess.Position, moves []*chess.Move) string {
+	moveNumber, blackToMove := startingMove(positions[0])
+
+	var tokens []string
+	for i, move := range moves {
+		if !blackToMove {
+			tokens = append(tokens, fmt.Sprintf("%d.", moveNumber))
+		} else if i == 0 {
+			tokens = append(tokens, fmt.Sprintf("%d...", moveNumber))
+		}
+
+		tokens = append(tokens, chess.AlgebraicNotation{}.Encode(positions[i], move))
+
+		if blackToMove {
+			moveNumber++
+		}
+		blackToMove = !blackToMove
+	}
+
+	return strings.Join(tokens, " ")
+}
+
+// linesSetMsg is sent once the engine has switched to the number of lines.
+type linesSetMsg struct {
+	multiPV int // lines the engine analyses
+	lines   int // lines shown in the panel, 0 when it is closed
+	err     error
+}
+
+// setLines sends the number of principal variations to the engine outside
+// the UI loop, since the engine takes it only once the running search ends.
+func setLines(a Analyzer, multiPV, lines int) tea.Cmd {
+	return func() tea.Msg {
+		option := uci.CmdSetOption{Name: "MultiPV", Value: strconv.Itoa(max(multiPV, 1))}
+		return linesSetMsg{multiPV: multiPV, lines: lines, err: a.SetOptions(option)}
+	}
+}
+
+// toggleLines opens or closes the lines panel, asking the engine for the
+// matching number of principal variations: the configured number, or
+// defaultMultiPV when the panel opens and the engine analyses a single line.
+// No search starts until the engine has the new number.
+func (m *Model) toggleLines() tea.Cmd {
+	if !m.hasEngine() {
+		m.status = "Attach an engine with 'E' to see its lines"
+		return nil
+	}
+	if m.settingLines {
+		return nil
+	}
+
+	multiPV, lines := m.engineMultiPV, 0
+	if m.multiPV == 0 {
+		lines = m.engineMultiPV
+		if lines <= 1 {
+			multiPV, lines = defaultMultiPV, defaultMultiPV
+		}
+	} else {
+		m.stopPreview()
+	}
+
+	m.stopInfinite()
+	m.settingLines = true
+	return setLines(m.analyzer, multiPV, lines)
+}
+
+// handleLinesSet opens or closes the lines panel once the engine has the new
+// number of lines and resumes the searches.
+func (m *Model) handleLinesSet(msg linesSetMsg) tea.Cmd {
+	m.settingLines = false
+	if msg.err != nil {
+		slog.Error("error setting engine option", "multipv", msg.multiPV, "err", msg.err)
+		m.status = "Could not change the number of engine lines: " + msg.err.Error()
+		return m.requestSearch()
+	}
+	m.multiPV = msg.lines
+
+	// the cached result of the position has the old number of lines
+	delete(m.searchResults, m.gameEngine.Position().String())
+	return m.requestSearch()
+}
+
+// renderLines draws the best lines of the engine for the game position on the board.
+func (m *Model) renderLines() string {
+	rows := []string{analysisTitleStyle.Render("Lines")}
+
+	result, ok := m.shownResult()
+	if !ok || len(result.Lines) == 0 {
+		rows = append(rows, "not searched yet")
+		return analysisStyle.Render(strings.Join(rows, "\n"))
+	}
+
+	pos := m.gamePosition()
+	for i, info := range result.Lines[:min(len(result.Lines), m.multiPV)] {
+		moves, positions := resolveLine(pos, info.PV, lineLength)
+		row := fmt.Sprintf("%d. %6s  %s", i+1, formatScore(whiteScore(pos, info.Score)), formatLine(positions, moves))
+		if m.preview != nil && m.preview.rank == i+1 {
+			row = currentMoveStyle.Render(row)
+		}
+		rows = append(rows, row)
+	}
+
+	return analysisStyle.Render(strings.Join(rows, "\n"))
+}
+
+// startPreview shows the line of the given rank on the board, played to its end.
+func (m *Model) startPreview(rank int) {
+	result, ok := m.shownResult()
+	if !ok || rank < 1 || rank > min(len(result.Lines), m.multiPV) {
+		return
+	}
+
+	moves, positions := resolveLine(m.gamePosition(), result.Lines[rank-1].PV, lineLength)
+	m.deselectPiece()
+	m.preview = &linePreview{
+		rank:      rank,
+		moves:     moves,
+		positions: positions,
+		ply:       len(moves),
+	}
+}
+
+// stopPreview returns the board to the game position.
+func (m *Model) stopPreview() {
+	m.preview = nil
+}
+
+// handleLinesKey previews engine lines and reports whether the key was
+// handled: the digits pick a line, left and right step through it.
+func (m *Model) handleLinesKey(key string) bool {
+	if m.multiPV == 0 {
+		return false
+	}
+
+	if rank, err := strconv.Atoi(key); err == nil && len(key) == 1 {
+		m.startPreview(rank)
+		return true
+	}
+
+	if m.preview == nil {
+		return false
+	}
+
+	switch key {
+	case "left":
+		m.preview.ply = max(m.preview.ply-1, 0)
+	case "right":
+		m.preview.ply = min(m.preview.ply+1, len(m.preview.moves))
+	case "esc":
+		m.stopPreview()
+	default:
+		return false
+	}
+
+	return true
+}
+
+// previewText describes the previewed line for the header.
+func (m *Model) previewText() string {
+	return fmt.Sprintf(" Previewing line %d: move %d of %d (←/→ step, esc to return) ",
+		m.preview.rank, m.preview.ply, len(m.preview.moves))
+}
@@ -0,0 +1,98 @@
+package game
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/notnil/chess/uci"
+)
+
+type Mode int
+
+const (
+	ModeTwoPlayer Mode = iota // two humans share the board, the engine only suggests moves
+	ModeEngine                // a human plays against the engine
+)
+
+func (m Mode) String() string {
+	switch m {
+	case ModeTwoPlayer:
+		return "two-player"
+	case ModeEngine:
+		return "engine"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseMode converts a mode name (e.g., "engine") to a Mode.
+func ParseMode(s string) (Mode, error) {
+	switch s {
+	case "two-player":
+		return ModeTwoPlayer, nil
+	case "engine":
+		return ModeEngine, nil
+	default:
+		return ModeTwoPlayer, fmt.Errorf("unknown mode %q, expected two-player or engine", s)
+	}
+}
+
+// EngineSettings controls how strong the engine plays and how long it thinks.
+type EngineSettings struct {
+	MoveTime   time.Duration // time the engine may spend per move, 0 for no limit
+	Depth      int           // maximum search depth in plies, 0 for no limit
+	SkillLevel int           // UCI "Skill Level" (0-20), negative keeps the engine default
+	Elo        int           // UCI_Elo strength limit, 0 plays at full strength
+}
+
+func DefaultEngineSettings() EngineSettings {
+	return EngineSettings{
+		MoveTime:   time.Second,
+		SkillLevel: -1,
+	}
+}
+
+// SetOptionCmds returns the UCI commands that apply the strength settings to an engine.
+func (s EngineSettings) SetOptionCmds() []uci.Cmd {
+	var cmds []uci.Cmd
+	if s.SkillLevel >= 0 {
+		cmds = append(cmds, uci.CmdSetOption{Name: "Skill Level", Value: strconv.Itoa(s.SkillLevel)})
+	}
+	if s.Elo > 0 {
+		cmds = append(cmds,
+			uci.CmdSetOption{Name: "UCI_LimitStrength", Value: "true"},
+			uci.CmdSetOption{Name: "UCI_Elo", Value: strconv.Itoa(s.Elo)},
+		)
+	}
+
+	return cmds
+}
+
+func (s EngineSettings) goCmd() uci.CmdGo {
+	cmd := uci.CmdGo{MoveTime: s.MoveTime, Depth: s.Depth}
+	if cmd.MoveTime == 0 && cmd.Depth == 0 {
+		cmd.MoveTime = DefaultEngineSettings().MoveTime
+	}
+
+	return cmd
+}
+
+// SelectSide asks the user which side they want to play against the engine.
+func SelectSide() (Player, error) {
+	var side Player
+	form := huh.NewSelect[Player]().
+		Title("Play as").
+		Options(
+			huh.NewOption("White", PlayerWhite),
+			huh.NewOption("Black", PlayerBlack),
+		).
+		Value(&side)
+
+	if err := form.Run(); err != nil {
+		return PlayerWhite, err
+	}
+
+	return side, nil
+}
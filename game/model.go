@@ -4,7 +4,6 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
-	"os"
 	"regexp"
 	"slices"
 	"strings"
@@ -29,134 +28,267 @@ type Model struct {
 	selectedX, selectedY int    // Position of the selected piece
 	selectedPiece        Piece  // Piece that is selected
 	selected             bool   // Whether a piece is selected
+	rejected             bool   // Whether the last attempted move was illegal
+	rejectedX, rejectedY int    // Destination of the rejected move
+	flipped              bool   // Whether the board is drawn from black's side
+	autoFlip             bool   // Whether the board faces the human's color
+	width, height        int    // Terminal size, zero until the first tea.WindowSizeMsg
 	currentPlayer        Player
 	gameEngine           *chess.Game
-	enPassantTarget      string
 	book                 opening.Book
 
 	numberOfMove int
-	validMoves   []*chess.Move
 	gameHistory  string
-
-	chessEngine *uci.Engine
+	historyMoves []string // algebraic notation of every move played
+	startedAt    time.Time
+	pgnPath      string // file the game is saved to
+	status       string // feedback about the last action, shown in the footer
+
+	replay    *chess.Game   // loaded game being stepped through, if any
+	replayPly int           // number of replayed moves shown on the board
+	redoMoves []*chess.Move // taken back moves, the next one to redo last
+
+	viewing bool // whether an earlier position is shown instead of the live game
+	viewPly int  // number of moves played in the position being viewed
+
+	clock         *Clock // chess clock, nil when playing without time control
+	clockTicking  bool   // whether a clock tick is scheduled
+	flagged       bool   // whether a player ran out of time
+	flaggedPlayer Player // player who ran out of time
+
+	resultDismissed bool // whether the result overlay was closed to look at the board
+
+	mode           Mode
+	humanPlayer    Player // side played by the human in engine mode
+	engineSettings EngineSettings
+
+	analyzer        Analyzer
+	enginePath      string                 // executable started when an engine is attached at runtime
+	engineOptions   []uci.Cmd              // resource options sent to an engine attached at runtime
+	searchResults   map[string]Analysis    // engine results cached by FEN
+	engineMoves     map[string]*chess.Move // moves the engine chose to play, by FEN
+	searchingMove   bool                   // whether the running search chooses the engine's move
+	searchingFEN    string                 // position the engine is searching, if any
+	searchStopped   bool                   // whether "stop" was sent for the running search
+	infinite        bool                   // whether the human's positions are analysed until they change
+	searchInfinite  bool                   // whether the running search is infinite
+	analysisTicking bool                   // whether an analysis tick is pending
+	progress        Analysis               // lines found so far by the running infinite search
+	progressFEN     string                 // position of progress, if any
+	showAnalysis    bool                   // whether the analysis panel is shown
+	multiPV         int                    // engine lines shown in the lines panel, 0 when it is closed
+	engineMultiPV   int                    // lines the engine is configured to analyse, 0 for its default
+	settingLines    bool                   // whether the engine is being sent a new number of lines
+	preview         *linePreview           // engine line shown on the board, if any
+	showHints       bool                   // whether the engine's best move is drawn on the board
+	review          *gameReview            // review of the finished game, if any
+	showReview      bool                   // whether the review panel is shown
 }
 
-func InitialModel(eng *uci.Engine) *Model {
+func InitialModel(opts ...Option) *Model {
+	gameEngine := chess.NewGame(chess.UseNotation(chess.UCINotation{}))
+	startedAt := time.Now()
+
+	m := &Model{
+		board:          NewBoardFromPosition(gameEngine.Position()),
+		cursorX:        4, // Column 'e'
+		cursorY:        6, // Row '2' (reversed ranks: 6 for row 2)
+		selected:       false,
+		currentPlayer:  PlayerWhite,
+		gameEngine:     gameEngine,
+		book:           opening.NewBookECO(),
+		analyzer:       NoAnalyzer{},
+		engineSettings: DefaultEngineSettings(),
+		searchResults:  make(map[string]Analysis),
+		engineMoves:    make(map[string]*chess.Move),
+		startedAt:      startedAt,
+		pgnPath:        DefaultPGNPath(startedAt),
+	}
 
-	return &Model{
-		board:         NewBoard(),
-		cursorX:       4, // Column 'e'
-		cursorY:       6, // Row '2' (reversed ranks: 6 for row 2)
-		selected:      false,
-		currentPlayer: PlayerWhite,
-		gameEngine:    chess.NewGame(chess.UseNotation(chess.UCINotation{})),
-		book:          opening.NewBookECO(),
-		chessEngine:   eng,
+	for _, opt := range opts {
+		opt(m)
 	}
+	m.orient()
+
+	return m
 }
 
 func (m *Model) Init() tea.Cmd {
 	slog.Info("new game started...")
-	return nil
+	return tea.Batch(m.requestSearch(), m.syncClock(time.Now()), m.syncAnalysis())
 }
 
 func (m *Model) View() string {
-	re := lipgloss.NewRenderer(os.Stdout)
+	l := m.layout()
 
 	// create the table with alternating black and white squares
+	targets := m.legalTargets()
+	highlights := m.highlights()
+	hint, _ := m.bestMove()
 	t := table.New().
 		Border(lipgloss.HiddenBorder()).
 		BorderRow(false).
 		BorderColumn(false).
-		Rows(m.board.Display()...).
+		Rows(m.orientRows(m.boardRows(targets, hint.path, highlights))...).
 		StyleFunc(func(row, col int) lipgloss.Style {
-			if m.cursorX == col && m.cursorY == row-1 && m.selected {
-				return selectedStyle
-			} else if (row+col)%2 == 0 {
-				if m.cursorX == col && m.cursorY == row-1 {
-					return blackCursorStyle
-				}
-				return blackSquare
-			} else {
-				if m.cursorX == col && m.cursorY == row-1 {
-					return whiteCursorStyle
-				}
-				return whiteSquare
-			}
+			x, y := m.orientSquare(row-1, col)
+			h := highlights[x][y]
+			style := h.style((x+y)%2 == 1)
+
+			return l.squareStyle(style, targets[square{x, y}] || h.marked())
 		})
 
 	// Labels for ranks (1-8) and files (a-h)
-	labelStyle := re.NewStyle().Foreground(lipgloss.Color("241")).Align(lipgloss.Center)
-	ranks := labelStyle.Render(
-		strings.Join([]string{"\n      a", "b", "c", "d", "e", "f", "g", "h"}, "      "),
-	)
-	files := strings.Join([]string{
-		labelStyle.Render("\n 8"),
-		labelStyle.Render("\n\n 7"),
-		labelStyle.Render("\n\n 6"),
-		labelStyle.Render("\n\n 5"),
-		labelStyle.Render("\n\n 4"),
-		labelStyle.Render("\n\n 3"),
-		labelStyle.Render("\n\n 2"),
-		labelStyle.Render("\n\n 1"),
-	}, "\n")
-
-	header := labelStyle.Render("                      Terminal Chess\n")
-
-	// Render the PGN on the right side of the board
-	pgnStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("255"))
-	pgnMoves := "\n" + pgnStyle.Render(m.gameHistory)
+	fileLabels := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+	rankLabels := []string{"8", "7", "6", "5", "4", "3", "2", "1"}
+	if m.flipped {
+		slices.Reverse(fileLabels)
+		slices.Reverse(rankLabels)
+	}
 
-	footer := ranks
-	footerSelectedPiece := lipgloss.NewStyle().
-		Background(lipgloss.Color("#ffffff")).
-		Foreground(lipgloss.Color("#ffffff"))
-	if m.selected {
-		footer += fmt.Sprintf("\nSelected piece: %s\n",
-			footerSelectedPiece.Render(m.selectedPiece.Render()))
+	board := t.Render()
+	evalBar := ""
+	if m.hasEngine() {
+		evalBar = m.renderEvalBar(l)
 	}
 
-	footer += "\nCurrent player: " + m.currentPlayer.String()
-	if moves := m.gameEngine.Moves(); moves != nil && len(moves) != 0 {
-		footer += "\nOpening: " + m.book.Find(moves).Title() + "\n"
+	clocks := ""
+	if m.clock != nil {
+		clocks = m.renderClocks(lipgloss.Height(board))
 	}
 
-	if m.selected {
-		footer += "\nValid Moves:"
-		for _, v := range m.gameEngine.ValidMoves() {
-			if Position(m.selectedY, m.selectedX) == v.S1().String() {
-				footer += " " + v.String()
-			}
+	// Render the panels and the PGN on the right side of the board, no taller
+	// than the board so that the view fits the layout
+	var panels []string
+	if m.showResult() {
+		// keep a line of the moves below the overlay
+		result := m.renderResult()
+		if lipgloss.Height(result)+3 > lipgloss.Height(board) {
+			result = m.renderCompactResult()
 		}
+		panels = append(panels, "", result)
+	}
+
+	if m.showReview && m.review != nil {
+		panels = append(panels, "", m.renderReview())
 	}
 
-	cmdPos := uci.CmdPosition{Position: m.gameEngine.Position()}
-	cmdGo := uci.CmdGo{MoveTime: time.Second / 100}
-	if err := m.chessEngine.Run(cmdPos, cmdGo); err != nil {
-		panic(err)
+	if m.showAnalysis && m.hasEngine() {
+		panels = append(panels, "", m.renderAnalysis())
 	}
-	result := m.chessEngine.SearchResults()
-	footer += "\n" + fmt.Sprintf("Best Move: %s, Ponder: %s\n",
-		result.BestMove,
-		result.Ponder,
-	)
 
-	footer += "\n\nPress 'q' or 'Ctrl+C' to quit.\n"
+	if m.multiPV > 0 && m.hasEngine() {
+		panels = append(panels, "", m.renderLines())
+	}
+
+	pgnStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("255"))
+	panelHeight := 0
+	if len(panels) > 0 {
+		panelHeight = lipgloss.Height(lipgloss.JoinVertical(lipgloss.Left, panels...))
+	}
+	historyHeight := lipgloss.Height(board) - panelHeight - 1
+	pgnMoves := "\n" + pgnStyle.Render(m.historyWindow(m.shownPly()-1, historyHeight))
+	pgnMoves = clipLines(lipgloss.JoinVertical(lipgloss.Left, append(panels, pgnMoves)...), lipgloss.Height(board))
 
-	return header + lipgloss.JoinVertical(
+	return m.renderHeader() + lipgloss.JoinVertical(
 		lipgloss.Right,
 		lipgloss.JoinHorizontal(
 			lipgloss.Top,
-			lipgloss.JoinVertical(lipgloss.Left, "", files),
-			t.Render(),
+			l.rankLabels(rankLabels),
+			board,
+			evalBar,
+			clocks,
 			pgnMoves,
 		),
-	) + footer
+	) + l.fileLabels(fileLabels) + m.renderStatus()
+}
+
+// renderHeader draws the title above the board and whether the history is browsed.
+func (m *Model) renderHeader() string {
+	header := labelStyle.Render("                      Terminal Chess") + "\n"
+	if m.viewing {
+		header += historyIndicatorStyle.Render(fmt.Sprintf(" Viewing history: move %d of %d ",
+			m.viewPly, len(m.gameEngine.Moves()))) + "\n"
+	}
+	if m.preview != nil {
+		header += historyIndicatorStyle.Render(m.previewText()) + "\n"
+	}
+
+	return header
+}
+
+// renderStatus draws the game state and key help below the board.
+func (m *Model) renderStatus() string {
+	footer := ""
+	footerSelectedPiece := whiteSquare.Padding(0, 1)
+	if m.selected {
+		footer += fmt.Sprintf("\nSelected piece: %s\n",
+			footerSelectedPiece.Render(m.selectedPiece.Render()))
+	}
+
+	if m.viewing {
+		footer += "\nViewing history (shift+←/→ or [/] to step, esc to return to the game)"
+	}
+
+	if m.replay != nil {
+		footer += fmt.Sprintf("\nReplaying move %d of %d (←/→ step, ↑/↓ first/last, esc to play on)",
+			m.replayPly, len(m.replay.Moves()))
+	}
+
+	footer += "\nCurrent player: " + m.currentPlayer.String()
+	if m.mode == ModeEngine {
+		footer += fmt.Sprintf(" (you play %s against the engine)", m.humanPlayer)
+	}
+	if o := m.opening(); o != nil {
+		footer += "\nOpening: " + o.Title() + "\n"
+	}
+
+	if !m.hasEngine() {
+		footer += "\nBest Move: no engine\n"
+	} else if result, ok := m.liveResult(); ok {
+		footer += "\n" + fmt.Sprintf("Best Move: %s, Ponder: %s\n",
+			result.BestMove,
+			result.Ponder,
+		)
+	} else {
+		footer += "\nBest Move: searching...\n"
+	}
+
+	if m.status != "" {
+		footer += "\n" + m.status
+	}
+
+	footer += "\n\nPress 's' to save the game as PGN, 'o' to open one, 'f' to set a FEN position,"
+	footer += "\n'u' to undo, 'Ctrl+R' to redo, 'c' to copy the FEN, 'p' to copy the PGN,"
+	footer += "\n'x' to resign, 'd' to offer a draw, 'D' to claim a draw, 'F' to flip the board,"
+	footer += "\n'E' to attach an engine, 'a' to show the analysis, 'm' to show the engine lines (1-9 to preview),"
+	footer += "\n'A' to analyse your positions without a time limit, 'b' to show the best move on the board,"
+	footer += "\n'v' to review the finished game, 'q' or 'Ctrl+C' to quit.\n"
+
+	return footer
 }
 
 func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msgType := msg.(type) {
 	case tea.KeyMsg:
+		m.rejected = false
+
+		if m.showResult() && m.handleResultKey(msgType.String()) {
+			break
+		}
+
+		if m.handleHistoryKey(msgType.String()) {
+			break
+		}
+
+		if m.handleLinesKey(msgType.String()) {
+			break
+		}
+
+		if m.replay != nil && m.handleReplayKey(msgType.String()) {
+			break
+		}
+
 		switch msgType.String() {
 		case "left", "h":
 			m.moveCursorLeft()
@@ -170,6 +302,40 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.handleSelectOrMove()
 		case "i":
 			m.handleInputFromKeyboard()
+		case "s":
+			m.savePGN()
+		case "o":
+			m.handleOpenPGN()
+		case "f":
+			m.handleFENInput()
+		case "x":
+			m.resign()
+		case "d":
+			m.offerDraw()
+		case "D":
+			m.claimDraw()
+		case "u":
+			m.undo()
+		case "ctrl+r":
+			m.redo()
+		case "F":
+			m.flipBoard()
+		case "E":
+			m.attachEngine()
+		case "a":
+			m.toggleAnalysis()
+		case "m":
+			return m, m.toggleLines()
+		case "A":
+			m.toggleInfinite()
+		case "b":
+			m.toggleHints()
+		case "v":
+			m.toggleReview()
+		case "c":
+			m.copyFEN()
+		case "p":
+			m.copyPGN()
 		case "esc":
 			m.deselectPiece()
 		case "q", "ctrl+c":
@@ -178,38 +344,76 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.MouseMsg:
 		switch msgType.Action {
 		case tea.MouseActionPress:
+			m.rejected = false
 			m.handleMouseClick(msgType.X, msgType.Y)
 		default:
 
 		}
+	case tea.WindowSizeMsg:
+		m.width, m.height = msgType.Width, msgType.Height
+	case searchResultMsg:
+		// the engine may have played its move, which presses the clock
+		return m, tea.Batch(m.handleSearchResult(msgType), m.syncClock(time.Now()))
+	case clockTickMsg:
+		return m, m.handleClockTick(time.Time(msgType))
+	case analysisTickMsg:
+		return m, m.handleAnalysisTick()
+	case linesSetMsg:
+		return m, m.handleLinesSet(msgType)
+	}
+
+	return m, tea.Batch(m.requestSearch(), m.syncClock(time.Now()), m.syncAnalysis())
+}
+
+// opening returns the ECO opening matching the moves played so far, if any.
+func (m *Model) opening() *opening.Opening {
+	moves := m.gameEngine.Moves()
+	if len(moves) == 0 {
+		return nil
+	}
 
+	// the book only knows move orders from the standard starting position
+	if m.gameEngine.Positions()[0].String() != chess.StartingPosition().String() {
+		return nil
 	}
 
-	return m, nil
+	return m.book.Find(moves)
 }
 
-func (m *Model) moveCursorLeft() {
-	if m.cursorX > 0 {
-		m.cursorX--
+func (m *Model) savePGN() {
+	if err := m.SavePGN(m.pgnPath); err != nil {
+		slog.Error("error saving pgn", "path", m.pgnPath, "err", err)
+		m.status = "Could not save game: " + err.Error()
+		return
 	}
+
+	m.status = "Saved game to " + m.pgnPath
+}
+
+func (m *Model) moveCursorLeft() {
+	m.moveCursor(0, -1)
 }
 
 func (m *Model) moveCursorRight() {
-	if m.cursorX < boardSize-1 {
-		m.cursorX++
-	}
+	m.moveCursor(0, 1)
 }
 
 func (m *Model) moveCursorUp() {
-	if m.cursorY > 0 {
-		m.cursorY--
-	}
+	m.moveCursor(-1, 0)
 }
 
 func (m *Model) moveCursorDown() {
-	if m.cursorY < boardSize-1 {
-		m.cursorY++
+	m.moveCursor(1, 0)
+}
+
+// moveCursor moves the cursor one step as seen on screen, staying on the board.
+func (m *Model) moveCursor(dRow, dCol int) {
+	if m.flipped {
+		dRow, dCol = -dRow, -dCol
 	}
+
+	m.cursorY = min(max(m.cursorY+dRow, 0), boardSize-1)
+	m.cursorX = min(max(m.cursorX+dCol, 0), boardSize-1)
 }
 
 func (m *Model) deselectPiece() {
@@ -217,6 +421,15 @@ func (m *Model) deselectPiece() {
 }
 
 func (m *Model) handleInputFromKeyboard() {
+	if m.isEngineTurn() || m.outcome() != chess.NoOutcome {
+		return
+	}
+	m.stopPreview()
+
+	if m.viewing && !m.branchFromHistory() {
+		return
+	}
+
 	// Prompt the user to enter a move in UCI format (e.g., "e2e4")
 	form := huh.NewInput().
 		Title("Enter your move (e.g., e2e4):").
@@ -246,8 +459,8 @@ func (m *Model) handleInputFromKeyboard() {
 	from := move[:2]
 	to := move[2:]
 
-	m.selectedX, m.selectedY = coordinates(from)
-	m.cursorX, m.cursorY = coordinates(to)
+	m.selectedY, m.selectedX = coordinates(from)
+	m.cursorY, m.cursorX = coordinates(to)
 
 	m.selectPiece()
 	m.applyMove(from, to)
@@ -263,6 +476,22 @@ func isValidUCI(pos string) bool {
 }
 
 func (m *Model) handleSelectOrMove() {
+	// the previewed line is not on the board to move in, the first click leaves it
+	if m.preview != nil {
+		m.stopPreview()
+		return
+	}
+
+	if m.viewing && !m.branchFromHistory() {
+		return
+	}
+
+	// choosing another of your own pieces changes the selection
+	if m.selected && (m.cursorX != m.selectedX || m.cursorY != m.selectedY) && m.canSelect() {
+		m.selectPiece()
+		return
+	}
+
 	if m.selected {
 		from := coordsToUCI(m.selectedX, m.selectedY)
 		to := coordsToUCI(m.cursorX, m.cursorY)
@@ -294,16 +523,9 @@ func (m *Model) applyMove(from, to string) {
 	}
 
 	move := from + to
-
-	// Handle en passant
-	if m.selectedPiece.IsPawn() && m.enPassantTarget == to {
-		if m.selectedPiece.IsWhite() && m.cursorY == 2 {
-			m.board.grid[3][m.cursorX] = Empty // Remove captured black pawn
-			m.enPassantTarget = ""
-		} else if m.selectedPiece.IsBlack() && m.cursorY == 5 {
-			m.board.grid[4][m.cursorX] = Empty // Remove captured white pawn
-			m.enPassantTarget = ""
-		}
+	if _, ok := m.legalTargets()[square{m.cursorY, m.cursorX}]; !ok {
+		m.rejectMove(move)
+		return
 	}
 
 	// Handle pawn promotion
@@ -311,42 +533,150 @@ func (m *Model) applyMove(from, to string) {
 		move += m.handlePromotion()
 	}
 
-	for _, v := range m.gameEngine.Moves() {
-		m.validMoves = append(m.validMoves, v)
+	if !m.makeMove(move) {
+		m.rejectMove(move)
+		return
+	}
+
+	// a new move starts a new line, so the taken back moves can not be redone
+	m.redoMoves = nil
+
+	// playing a move of your own continues the game from the replayed position
+	if m.replay != nil {
+		m.stopReplay()
 	}
+}
 
+// makeMove plays a move given in UCI notation and refreshes the board and history.
+// It reports whether the move was legal.
+func (m *Model) makeMove(move string) bool {
 	if err := m.gameEngine.MoveStr(move); err != nil {
 		slog.Error("error from engine",
 			"move", move,
 			"err", err,
 		)
+		return false
+	}
+
+	m.UpdateGameHistory()
+
+	// the board always mirrors the engine position, so castling, en passant
+	// and promotion are resolved by the chess rules rather than by hand
+	m.board = NewBoardFromPosition(m.gameEngine.Position())
+	m.currentPlayer = m.currentPlayer.Switch()
+	m.selected = false
+	m.stopPreview()
+	m.logResult()
+
+	return true
+}
+
+// isEngineTurn reports whether the engine is to move in engine mode.
+func (m *Model) isEngineTurn() bool {
+	return m.mode == ModeEngine && m.replay == nil && m.currentPlayer != m.humanPlayer
+}
+
+// resetGame starts over from the position described by fen, clearing the history.
+func (m *Model) resetGame(fen string) error {
+	fenFunc, err := chess.FEN(fen)
+	if err != nil {
+		return err
+	}
+
+	m.gameEngine = chess.NewGame(chess.UseNotation(chess.UCINotation{}), fenFunc)
+	m.board = NewBoardFromPosition(m.gameEngine.Position())
+	m.currentPlayer = playerFromColor(m.gameEngine.Position().Turn())
+	m.numberOfMove = 0
+	m.gameHistory = ""
+	m.historyMoves = nil
+	m.selected = false
+	m.redoMoves = nil
+	m.resultDismissed = false
+	m.showLive()
+
+	return nil
+}
+
+// rebuildGame starts over from the position described by fen and plays the given moves.
+func (m *Model) rebuildGame(fen string, moves []*chess.Move) error {
+	if err := m.resetGame(fen); err != nil {
+		return err
+	}
+
+	for _, move := range moves {
+		m.playMove(move)
+	}
+
+	return nil
+}
+
+// pliesPerTurn is the number of moves taken back or redone at once: in engine
+// mode the engine's reply goes together with the player's move.
+func (m *Model) pliesPerTurn() int {
+	if m.mode == ModeEngine && !m.isEngineTurn() {
+		return 2
+	}
+	return 1
+}
+
+// undo takes back the last move by rebuilding the game without it.
+func (m *Model) undo() {
+	if m.replay != nil {
 		return
 	}
 
-	m.UpdateGameHistory(move)
-	m.validMoves = m.gameEngine.ValidMoves()
+	moves := m.gameEngine.Moves()
+	plies := m.pliesPerTurn()
+	if len(moves) < plies {
+		m.status = "Nothing to undo"
+		return
+	}
 
-	defer func() {
-		m.currentPlayer = m.currentPlayer.Switch()
-		m.selected = false
-	}()
+	redo := m.redoMoves
+	for i := len(moves) - 1; i >= len(moves)-plies; i-- {
+		redo = append(redo, moves[i])
+	}
 
-	if m.selectedPiece.IsPawn() && abs(m.selectedY-m.cursorY) == 2 {
-		m.enPassantTarget = coordsToUCI(m.cursorX, (m.selectedY+m.cursorY)/2)
+	if err := m.rebuildGame(m.gameEngine.Positions()[0].String(), moves[:len(moves)-plies]); err != nil {
+		slog.Error("error taking back move", "err", err)
+		return
 	}
 
-	if m.selectedPiece.IsKing() && slices.Contains([]string{"e1g1", "e1c1", "e8g8", "e8c8"}, move) {
-		m.handleCastling(move)
+	m.redoMoves = redo
+	m.status = ""
+}
+
+// redo replays the moves taken back by the last undo.
+func (m *Model) redo() {
+	if m.replay != nil {
 		return
 	}
 
-	// Regular move
-	m.board.Set(m.cursorY, m.cursorX, m.selectedPiece)
-	m.board.grid[m.selectedY][m.selectedX] = Empty
-	m.selected = false
+	if len(m.redoMoves) == 0 {
+		m.status = "Nothing to redo"
+		return
+	}
+
+	for plies := m.pliesPerTurn(); plies > 0 && len(m.redoMoves) > 0; plies-- {
+		move := m.redoMoves[len(m.redoMoves)-1]
+		m.redoMoves = m.redoMoves[:len(m.redoMoves)-1]
+		m.playMove(move)
+	}
+
+	m.status = ""
+}
+
+// playMove plays an already decoded move through the same path as a human move.
+func (m *Model) playMove(move *chess.Move) {
+	m.makeMove(chess.UCINotation{}.Encode(nil, move))
 }
 
 func (m *Model) canSelect() bool {
+	// the human can not move for the engine or after the game ended
+	if m.isEngineTurn() || m.outcome() != chess.NoOutcome {
+		return false
+	}
+
 	// no player can select an empty space
 	if m.board.Get(m.cursorY, m.cursorX) == Empty {
 		return false
@@ -412,43 +742,9 @@ func (m *Model) handlePromotion() string {
 	var piece string
 	form.Value(&piece)
 
-	m.updateBoardForPromotion(piece)
 	return piece
 }
 
-func (m *Model) updateBoardForPromotion(piece string) {
-	promotedPiece := m.getPromotionPiece(piece)
-	m.selectedPiece = promotedPiece
-}
-
-func (m *Model) getPromotionPiece(piece string) Piece {
-	switch piece {
-	case "q":
-		if m.selectedPiece.IsWhite() {
-			return WhiteQueen
-		}
-		return BlackQueen
-	case "r":
-		if m.selectedPiece.IsWhite() {
-			return WhiteRook
-		}
-		return BlackRook
-	case "b":
-		if m.selectedPiece.IsWhite() {
-			return WhiteBishop
-		}
-		return BlackBishop
-	case "n":
-		if m.selectedPiece.IsWhite() {
-			return WhiteKnight
-		}
-		return BlackKnight
-	default:
-		// If for some reason an invalid piece is provided, return Empty
-		return Empty
-	}
-}
-
 func splitPGN(pgn string) []string {
 	// Regular expression to match move numbers (e.g., 1., 2., 3.)
 	re := regexp.MustCompile(`\d+\.`)
@@ -484,179 +780,25 @@ func splitPGN(pgn string) []string {
 }
 
 func (m *Model) handleMouseClick(x, y int) {
-	boardOffsetX := 2
-	boardOffsetY := 2
-	cellWidth := 7
-	cellHeight := 3
-
-	col := (x - boardOffsetX) / cellWidth
-	row := (y - boardOffsetY) / cellHeight
-
-	if col >= 0 && col < boardSize && row >= 0 && row < boardSize {
-		m.cursorX = col
-		m.cursorY = row
+	if row, col, ok := m.layout().squareAt(x, y); ok {
+		m.cursorY, m.cursorX = m.orientSquare(row, col)
 		m.handleSelectOrMove()
 	}
 }
 
-func (m *Model) handleCastling(move string) {
-	var kingRow, rookCol, kingNewCol, rookNewCol int
-
-	switch move {
-	case "e1g1": // White king-side castling
-		if !m.board.Get(7, 7).IsRook() {
-			return
-		}
-
-		kingRow, rookCol, kingNewCol, rookNewCol = 7, 7, 6, 5
-	case "e1c1": // White queen-side castling
-		if !m.board.Get(7, 0).IsRook() {
-			return
-		}
-
-		kingRow, rookCol, kingNewCol, rookNewCol = 7, 0, 2, 3
-	case "e8g8": // Black king-side castling
-		if !m.board.Get(0, 7).IsRook() {
-			return
-		}
-
-		kingRow, rookCol, kingNewCol, rookNewCol = 0, 7, 6, 5
-	case "e8c8": // Black queen-side castling
-		if !m.board.Get(0, 0).IsRook() {
-			return
-		}
-		kingRow, rookCol, kingNewCol, rookNewCol = 0, 0, 2, 3
-	default:
-		return // Invalid move for castling
-	}
-
-	// Move the king and rook using the Set method
-	king := m.board.Get(kingRow, 4)
-	rook := m.board.Get(kingRow, rookCol)
-
-	m.board.Set(kingRow, kingNewCol, king)
-	m.board.Set(kingRow, rookNewCol, rook)
-
-	// Clear the original squares
-	m.board.Set(kingRow, 4, Empty)
-	m.board.Set(kingRow, rookCol, Empty)
-}
-
-func abs(a int) int {
-	if a > 0 {
-		return a
-	}
-	return -a
-}
-
-// unitAlgebraic converts UCI notation (e.g., "e2e4") to algebraic notation (e.g., "Ne4" or "e4").
-func (m *Model) unitAlgebraic(move string) (string, error) {
-	// Validate input length
-	if len(move) != 4 {
-		return "", errors.New("invalid UCI move format")
-	}
-
-	from := move[:2]
-	to := move[2:]
-
-	// Handle castling
-	if m.selectedPiece.IsKing() {
-		if from == "e1" && to == "g1" { // White king-side castling
-			return "O-O", nil
-		}
-		if from == "e1" && to == "c1" { // White queen-side castling
-			return "O-O-O", nil
-		}
-		if from == "e8" && to == "g8" { // Black king-side castling
-			return "O-O", nil
-		}
-		if from == "e8" && to == "c8" { // Black queen-side castling
-			return "O-O-O", nil
-		}
-	}
-
-	// Convert the move to algebraic notation
-	var algebraicMove string
-
-	if m.selectedPiece.IsPawn() {
-		// Handle pawn captures (e.g., "exd5")
-		if !m.board.Get(m.cursorY, m.cursorX).IsEmpty() {
-			algebraicMove = string(from[0]) + "x" + to
-		} else {
-			algebraicMove = to
-		}
-	} else if m.selectedPiece.IsKnight() || m.selectedPiece.IsRook() || m.selectedPiece.IsQueen() {
-		algebraicMove += m.selectedPiece.Name()
-
-		ambiguous := 0
-		for _, pm := range m.validMoves {
-			if pm.S2().String() != to {
-				continue
-			}
-
-			if m.board.Get(coordinates(pm.S1().String())) == m.board.Get(coordinates(from)) {
-				ambiguous++
-			}
-		}
-
-		if ambiguous > 1 {
-			algebraicMove += string(from[0])
-		}
-		if !m.board.Get(m.cursorY, m.cursorX).IsEmpty() {
-			algebraicMove += "x"
-		}
-
-		algebraicMove += to
-	} else {
-		// Handle captures and piece moves (e.g., "Nxf3", "Qd2")
-		if !m.board.Get(m.cursorY, m.cursorX).IsEmpty() {
-			algebraicMove = m.selectedPiece.Name() + "x" + to
-		} else {
-			algebraicMove = m.selectedPiece.Name() + to
-		}
-	}
-
-	if outcome := m.gameEngine.Outcome(); outcome != chess.NoOutcome {
-		switch outcome {
-		case chess.WhiteWon, chess.BlackWon:
-			algebraicMove += "# " + outcome.String()
-		case chess.Draw:
-			algebraicMove += " " + outcome.String()
-		}
-	} else {
-		if chess.IsInCheck(m.gameEngine.Position()) {
-			algebraicMove += "+"
-		}
-	}
-
-	return algebraicMove, nil
-}
-
-// UpdateGameHistory updates the move history in the desired format like "1. e4 e5 2. ...".
-func (m *Model) UpdateGameHistory(move string) {
+// UpdateGameHistory records the last move in standard algebraic notation
+// (e.g., "Nbd2", "exd6", "a8=Q+", "O-O-O" or "Qh4#") and refreshes the history.
+func (m *Model) UpdateGameHistory() {
 	m.numberOfMove += 1 // Increment the move count
 
-	// Convert UCI notation to algebraic notation
-	position, err := m.unitAlgebraic(move)
-	if err != nil {
-		slog.Error("error from engine",
-			"move", move,
-			"err", err,
-		)
-		return
-	}
-
-	// Determine if it's white's or black's move based on move number
-	moveNumber := (m.numberOfMove + 1) / 2 // The actual move number in the game
+	// the move is encoded against the position it was played from, which
+	// resolves disambiguation, captures, promotions, check and mate
+	positions := m.gameEngine.Positions()
+	moves := m.gameEngine.Moves()
+	san := chess.AlgebraicNotation{}.Encode(positions[len(positions)-2], moves[len(moves)-1])
 
-	// If it's white's move
-	if m.numberOfMove%2 == 1 {
-		// Start a new line with the move number
-		m.gameHistory += fmt.Sprintf("\n%d. %s", moveNumber, position)
-	} else { // If it's black's move
-		// Append to the existing line
-		m.gameHistory += fmt.Sprintf(" %s", position)
-	}
+	m.historyMoves = append(m.historyMoves, san)
+	m.gameHistory = m.formatHistory(-1)
 
 	// Example output: "1. e4 e5\n2. Nf3 Nc6"
 }
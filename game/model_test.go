@@ -0,0 +1,200 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestUpdateGameHistory(t *testing.T) {
+	tests := []struct {
+		name  string
+		fen   string
+		moves []string
+		want  string
+	}{
+		{
+			name:  "pawn push",
+			fen:   "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+			moves: []string{"e2e4"},
+			want:  "e4",
+		},
+		{
+			name:  "pawn capture",
+			fen:   "rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 2",
+			moves: []string{"e4d5"},
+			want:  "exd5",
+		},
+		{
+			name:  "knights on the same rank",
+			fen:   "4k3/8/8/8/8/8/8/1N2KN2 w - - 0 1",
+			moves: []string{"b1d2"},
+			want:  "Nbd2",
+		},
+		{
+			name:  "knights on the same file",
+			fen:   "7k/8/8/1N6/8/8/8/1N5K w - - 0 1",
+			moves: []string{"b1c3"},
+			want:  "N1c3",
+		},
+		{
+			name:  "rooks on the same rank",
+			fen:   "4k3/8/8/8/R6R/8/8/4K3 w - - 0 1",
+			moves: []string{"a4d4"},
+			want:  "Rad4",
+		},
+		{
+			name:  "rooks on the same file",
+			fen:   "7k/3R4/8/8/8/8/8/3R3K w - - 0 1",
+			moves: []string{"d1d4"},
+			want:  "R1d4",
+		},
+		{
+			name:  "bishops on different files and ranks",
+			fen:   "7k/8/8/6B1/8/8/8/2B4K w - - 0 1",
+			moves: []string{"c1e3"},
+			want:  "Bce3",
+		},
+		{
+			name:  "queens needing file and rank",
+			fen:   "8/7k/8/Q7/8/8/8/Q3Q2K w - - 0 1",
+			moves: []string{"a1e5"},
+			want:  "Qa1e5",
+		},
+		{
+			name:  "king move",
+			fen:   "4k3/8/8/8/8/8/8/4K3 w - - 0 1",
+			moves: []string{"e1d2"},
+			want:  "Kd2",
+		},
+		{
+			name:  "en passant",
+			fen:   "4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1",
+			moves: []string{"e5d6"},
+			want:  "exd6",
+		},
+		{
+			name:  "promotion with check",
+			fen:   "4k3/P7/8/8/8/8/8/4K3 w - - 0 1",
+			moves: []string{"a7a8q"},
+			want:  "a8=Q+",
+		},
+		{
+			name:  "underpromotion",
+			fen:   "4k3/P7/8/8/8/8/8/4K3 w - - 0 1",
+			moves: []string{"a7a8n"},
+			want:  "a8=N",
+		},
+		{
+			name:  "capture with promotion",
+			fen:   "r3k3/1P6/8/8/8/8/8/4K3 w - - 0 1",
+			moves: []string{"b7a8q"},
+			want:  "bxa8=Q+",
+		},
+		{
+			name:  "short castle with check",
+			fen:   "5k2/8/8/8/8/8/8/4K2R w K - 0 1",
+			moves: []string{"e1g1"},
+			want:  "O-O+",
+		},
+		{
+			name:  "long castle",
+			fen:   "4k3/8/8/8/8/8/8/R3K3 w Q - 0 1",
+			moves: []string{"e1c1"},
+			want:  "O-O-O",
+		},
+		{
+			name:  "checkmate",
+			fen:   "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+			moves: []string{"f2f3", "e7e5", "g2g4", "d8h4"},
+			want:  "Qh4#",
+		},
+	}
+
+	// building the opening book is slow, so one model is reset for every case
+	m := InitialModel()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := m.SetPosition(tt.fen); err != nil {
+				t.Fatalf("SetPosition(%q) error = %v", tt.fen, err)
+			}
+
+			for _, move := range tt.moves {
+				if !m.makeMove(move) {
+					t.Fatalf("makeMove(%q) rejected", move)
+				}
+			}
+
+			if got := m.historyMoves[len(m.historyMoves)-1]; got != tt.want {
+				t.Errorf("last move = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestViewFitsTerminal(t *testing.T) {
+	m := InitialModel()
+	m.width, m.height = 160, 50
+
+	// push a pawn of each side, then shuffle the knights, for 84 plies
+	for _, file := range "abdefgh" {
+		for step := 0; step < 2; step++ {
+			moves := []string{
+				fmt.Sprintf("%c%d%c%d", file, 2+step, file, 3+step),
+				fmt.Sprintf("%c%d%c%d", file, 7-step, file, 6-step),
+				"b1c3", "b8c6", "c3b1", "c6b8",
+			}
+			for _, move := range moves {
+				if !m.makeMove(move) {
+					t.Fatalf("move %s rejected at ply %d", move, len(m.gameEngine.Moves()))
+				}
+			}
+		}
+	}
+
+	if got := lipgloss.Height(m.View()); got > m.height {
+		t.Errorf("view is %d lines tall, want at most %d", got, m.height)
+	}
+}
+
+func TestResultFitsCompactBoard(t *testing.T) {
+	m := InitialModel()
+	m.width, m.height = 100, 30
+	for _, move := range []string{"f2f3", "e7e5", "g2g4", "d8h4"} {
+		if !m.makeMove(move) {
+			t.Fatalf("move %s rejected", move)
+		}
+	}
+
+	view := m.View()
+	for _, want := range []string{"n: new game", "esc: look at the board", "╰", "Qh4#"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view lacks %q:\n%s", want, view)
+		}
+	}
+}
+
+func TestMonochromeMarksHighlights(t *testing.T) {
+	if err := SetTheme("monochrome"); err != nil {
+		t.Fatal(err)
+	}
+	defer SetTheme(DefaultThemeName)
+
+	m := InitialModel()
+	for _, move := range []string{"e2e4", "f7f6", "d2d4", "g7g5", "d1h5"} {
+		if !m.makeMove(move) {
+			t.Fatalf("move %s rejected", move)
+		}
+	}
+
+	view := ansi.Strip(m.View())
+	for _, want := range []string{"!♚!", "-♕-"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view lacks %q:\n%s", want, view)
+		}
+	}
+}
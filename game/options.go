@@ -0,0 +1,87 @@
+package game
+
+import (
+	"log/slog"
+
+	"github.com/notnil/chess"
+	"github.com/notnil/chess/uci"
+)
+
+// Option configures the model created by InitialModel.
+type Option func(*Model)
+
+// WithEngineOpponent lets the human play the given side against the engine.
+func WithEngineOpponent(human Player, settings EngineSettings) Option {
+	return func(m *Model) {
+		m.mode = ModeEngine
+		m.humanPlayer = human
+		m.engineSettings = settings
+	}
+}
+
+// WithPGNPath sets the file the game is saved to.
+func WithPGNPath(path string) Option {
+	return func(m *Model) {
+		m.pgnPath = path
+	}
+}
+
+// WithReplay starts by replaying a game loaded from a PGN file.
+func WithReplay(g *chess.Game) Option {
+	return func(m *Model) {
+		m.startReplay(g)
+	}
+}
+
+// WithFEN starts the game from the position described by fen, which should
+// have been checked with ValidateFEN.
+func WithFEN(fen string) Option {
+	return func(m *Model) {
+		if err := m.SetPosition(fen); err != nil {
+			slog.Error("error setting position", "fen", fen, "err", err)
+		}
+	}
+}
+
+// WithTimeControl plays the game with a chess clock.
+func WithTimeControl(tc TimeControl) Option {
+	return func(m *Model) {
+		m.clock = NewClock(tc)
+	}
+}
+
+// WithAutoFlip draws the board from the side the human plays against the engine.
+func WithAutoFlip() Option {
+	return func(m *Model) {
+		m.autoFlip = true
+	}
+}
+
+// WithAnalyzer analyses the game with the given engine.
+func WithAnalyzer(a Analyzer) Option {
+	return func(m *Model) {
+		m.analyzer = a
+	}
+}
+
+// WithEngineSetup sets how an engine attached at runtime is started: the
+// executable, its strength when playing against it and its resource options.
+func WithEngineSetup(path string, settings EngineSettings, options ...uci.Cmd) Option {
+	return func(m *Model) {
+		m.enginePath = path
+		m.engineSettings = settings
+		m.engineOptions = options
+	}
+}
+
+// WithMultiPV sets the number of lines the engine is configured to analyse.
+// The lines panel shows that many lines and starts open when there are more
+// than one.
+func WithMultiPV(n int) Option {
+	return func(m *Model) {
+		m.engineMultiPV = n
+		if n > 1 {
+			m.multiPV = n
+		}
+	}
+}
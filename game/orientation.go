@@ -0,0 +1,42 @@
+package game
+
+// orientSquare converts between a square as drawn on screen and its board
+// coordinates. From black's side the board is turned by half a turn, so the
+// conversion is its own inverse.
+func (m *Model) orientSquare(row, col int) (int, int) {
+	if m.flipped {
+		return boardSize - 1 - row, boardSize - 1 - col
+	}
+	return row, col
+}
+
+// orientRows arranges the rendered board as it is drawn on screen.
+func (m *Model) orientRows(rows [][]string) [][]string {
+	if !m.flipped {
+		return rows
+	}
+
+	oriented := make([][]string, len(rows))
+	for i := range rows {
+		oriented[i] = make([]string, len(rows[i]))
+		for j := range rows[i] {
+			x, y := m.orientSquare(i, j)
+			oriented[i][j] = rows[x][y]
+		}
+	}
+
+	return oriented
+}
+
+// flipBoard turns the board around to be seen from the other side.
+func (m *Model) flipBoard() {
+	m.flipped = !m.flipped
+}
+
+// orient turns the board to face the human's color against the engine when
+// auto-flip is on.
+func (m *Model) orient() {
+	if m.autoFlip && m.mode == ModeEngine {
+		m.flipped = m.humanPlayer == PlayerBlack
+	}
+}
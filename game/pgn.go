@@ -0,0 +1,189 @@
+package game
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/notnil/chess"
+)
+
+const (
+	pgnDateFormat = "2006.01.02"
+	pgnLineLength = 80 // export format keeps move text lines short
+)
+
+// unknownTag is the PGN placeholder for a tag value that is not known.
+const unknownTag = "?"
+
+// tagPair is a single PGN header, e.g. [Event "Casual game"].
+type tagPair struct {
+	key, value string
+}
+
+// DefaultPGNPath returns a file name for a game started at the given time.
+func DefaultPGNPath(startedAt time.Time) string {
+	return "termchess-" + startedAt.Format("20060102-150405") + ".pgn"
+}
+
+// PGN renders the game in PGN export format with the Seven Tag Roster,
+// the ECO opening tags and the move text in standard algebraic notation.
+// Once the game has been reviewed, the moves carry NAGs and eval comments.
+func (m *Model) PGN() string {
+	var sb strings.Builder
+	for _, tag := range m.pgnTags() {
+		fmt.Fprintf(&sb, "[%s %q]\n", tag.key, tag.value)
+	}
+
+	sb.WriteString("\n")
+	sb.WriteString(wrapPGN(m.pgnMoveText(), pgnLineLength))
+	sb.WriteString("\n")
+
+	return sb.String()
+}
+
+// SavePGN writes the game in PGN format to the given file.
+func (m *Model) SavePGN(path string) error {
+	return os.WriteFile(path, []byte(m.PGN()), 0644)
+}
+
+func (m *Model) pgnTags() []tagPair {
+	tags := []tagPair{
+		{"Event", "Casual game"},
+		{"Site", "termchess"},
+		{"Date", m.startedAt.Format(pgnDateFormat)},
+		{"Round", "-"},
+		{"White", m.playerName(PlayerWhite)},
+		{"Black", m.playerName(PlayerBlack)},
+		{"Result", m.outcome().String()},
+	}
+
+	if m.clock != nil {
+		tc := m.clock.control
+		tags = append(tags, tagPair{"TimeControl", fmt.Sprintf("%d+%d", int(tc.Base/time.Second), int(tc.Increment/time.Second))})
+	}
+
+	if m.flagged {
+		tags = append(tags, tagPair{"Termination", "time forfeit"})
+	}
+
+	if o := m.opening(); o != nil {
+		tags = append(tags,
+			tagPair{"ECO", o.Code()},
+			tagPair{"Opening", o.Title()},
+		)
+	}
+
+	if start := m.gameEngine.Positions()[0].String(); start != chess.StartingPosition().String() {
+		tags = append(tags,
+			tagPair{"SetUp", "1"},
+			tagPair{"FEN", start},
+		)
+	}
+
+	return tags
+}
+
+// playerName returns the name recorded in the PGN for the given side.
+func (m *Model) playerName(p Player) string {
+	if m.mode == ModeEngine && p != m.humanPlayer {
+		if name := m.analyzer.Name(); name != "" {
+			return name
+		}
+		return "Engine"
+	}
+
+	return unknownTag
+}
+
+// pgnMoveText returns the moves in SAN with move numbers, followed by the result.
+func (m *Model) pgnMoveText() string {
+	positions := m.gameEngine.Positions()
+	moveNumber, blackToMove := startingMove(positions[0])
+
+	var tokens []string
+	annotated := false
+	for i, move := range m.gameEngine.Moves() {
+		if !blackToMove {
+			tokens = append(tokens, fmt.Sprintf("%d.", moveNumber))
+		} else if i == 0 || annotated {
+			// the move number is repeated for black after a NAG or a comment
+			tokens = append(tokens, fmt.Sprintf("%d...", moveNumber))
+		}
+
+		tokens = append(tokens, chess.AlgebraicNotation{}.Encode(positions[i], move))
+		annotation := m.pgnAnnotation(i)
+		tokens = append(tokens, annotation...)
+		annotated = len(annotation) > 0
+
+		if blackToMove {
+			moveNumber++
+		}
+		blackToMove = !blackToMove
+	}
+
+	tokens = append(tokens, m.outcome().String())
+	return strings.Join(tokens, " ")
+}
+
+// startingMove returns the full move number of a position and whether black is to move.
+func startingMove(pos *chess.Position) (int, bool) {
+	moveNumber := 1
+	if fields := strings.Fields(pos.String()); len(fields) == 6 {
+		if n, err := strconv.Atoi(fields[5]); err == nil {
+			moveNumber = n
+		}
+	}
+
+	return moveNumber, pos.Turn() == chess.Black
+}
+
+// wrapPGN breaks move text into lines no longer than width. Comments are
+// kept on one line, even a longer one, so that a line never starts inside a
+// comment, where "[%eval" would read as a tag pair.
+func wrapPGN(text string, width int) string {
+	var lines []string
+	line := ""
+	for _, token := range pgnTokens(text) {
+		if line != "" && len(line)+1+len(token) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += token
+	}
+
+	return strings.Join(append(lines, line), "\n")
+}
+
+// pgnTokens splits move text at spaces, keeping every comment in braces as one token.
+func pgnTokens(text string) []string {
+	var tokens []string
+	comment := ""
+	for _, field := range strings.Fields(text) {
+		switch {
+		case comment != "":
+			comment += " " + field
+		case strings.HasPrefix(field, "{"):
+			comment = field
+		default:
+			tokens = append(tokens, field)
+			continue
+		}
+
+		if strings.HasSuffix(field, "}") {
+			tokens = append(tokens, comment)
+			comment = ""
+		}
+	}
+
+	if comment != "" {
+		tokens = append(tokens, comment)
+	}
+
+	return tokens
+}
@@ -1,5 +1,13 @@
 package game
 
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/notnil/chess"
+)
+
 type Piece int
 
 const (
@@ -18,24 +26,112 @@ const (
 	BlackKing
 )
 
-var PieceMap = map[Piece]string{
-	WhitePawn:   "♙",
-	WhiteRook:   "♖",
-	WhiteKnight: "♘",
-	WhiteBishop: "♗",
-	WhiteQueen:  "♕",
-	WhiteKing:   "♔",
-	BlackPawn:   "♟",
-	BlackRook:   "♜",
-	BlackKnight: "♞",
-	BlackBishop: "♝",
-	BlackQueen:  "♛",
-	BlackKing:   "♚",
-	Empty:       " ", // Represents an empty square
+// DefaultPieceSetName is the glyph set pieces are drawn with unless another is chosen.
+const DefaultPieceSetName = "unicode"
+
+// PieceSet maps every piece to the glyph it is drawn with.
+type PieceSet map[Piece]string
+
+// pieceSets are the glyph sets the pieces can be drawn with, by name.
+var pieceSets = map[string]PieceSet{
+	"unicode": {
+		WhitePawn:   "♙",
+		WhiteRook:   "♖",
+		WhiteKnight: "♘",
+		WhiteBishop: "♗",
+		WhiteQueen:  "♕",
+		WhiteKing:   "♔",
+		BlackPawn:   "♟",
+		BlackRook:   "♜",
+		BlackKnight: "♞",
+		BlackBishop: "♝",
+		BlackQueen:  "♛",
+		BlackKing:   "♚",
+		Empty:       " ", // Represents an empty square
+	},
+	"ascii": {
+		WhitePawn:   "P",
+		WhiteRook:   "R",
+		WhiteKnight: "N",
+		WhiteBishop: "B",
+		WhiteQueen:  "Q",
+		WhiteKing:   "K",
+		BlackPawn:   "p",
+		BlackRook:   "r",
+		BlackKnight: "n",
+		BlackBishop: "b",
+		BlackQueen:  "q",
+		BlackKing:   "k",
+		Empty:       " ",
+	},
+	// Nerd Font chess icons are filled for both sides, the theme colors tell them apart
+	"nerd": {
+		WhitePawn:   "\uf443",
+		WhiteRook:   "\uf447",
+		WhiteKnight: "\uf441",
+		WhiteBishop: "\uf43a",
+		WhiteQueen:  "\uf445",
+		WhiteKing:   "\uf43f",
+		BlackPawn:   "\uf443",
+		BlackRook:   "\uf447",
+		BlackKnight: "\uf441",
+		BlackBishop: "\uf43a",
+		BlackQueen:  "\uf445",
+		BlackKing:   "\uf43f",
+		Empty:       " ",
+	},
+}
+
+// pieceSet is the glyph set the pieces are drawn with.
+var pieceSet = pieceSets[DefaultPieceSetName]
+
+// PieceSetNames returns the names of the available glyph sets in alphabetical order.
+func PieceSetNames() []string {
+	names := make([]string, 0, len(pieceSets))
+	for name := range pieceSets {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return names
+}
+
+// SetPieceSet draws the pieces with the named glyph set.
+func SetPieceSet(name string) error {
+	set, ok := pieceSets[name]
+	if !ok {
+		return fmt.Errorf("unknown piece set %q, expected one of %s", name, strings.Join(PieceSetNames(), ", "))
+	}
+
+	pieceSet = set
+	return nil
+}
+
+var chessPieceMap = map[chess.Piece]Piece{
+	chess.WhitePawn:   WhitePawn,
+	chess.WhiteRook:   WhiteRook,
+	chess.WhiteKnight: WhiteKnight,
+	chess.WhiteBishop: WhiteBishop,
+	chess.WhiteQueen:  WhiteQueen,
+	chess.WhiteKing:   WhiteKing,
+	chess.BlackPawn:   BlackPawn,
+	chess.BlackRook:   BlackRook,
+	chess.BlackKnight: BlackKnight,
+	chess.BlackBishop: BlackBishop,
+	chess.BlackQueen:  BlackQueen,
+	chess.BlackKing:   BlackKing,
+}
+
+// pieceFromChess converts a notnil/chess piece to its board representation.
+func pieceFromChess(p chess.Piece) Piece {
+	if piece, exists := chessPieceMap[p]; exists {
+		return piece
+	}
+	return Empty
 }
 
 func (p Piece) String() string {
-	if emoji, exists := PieceMap[p]; exists {
+	if emoji, exists := pieceSet[p]; exists {
 		return emoji
 	}
 	return " "
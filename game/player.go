@@ -1,5 +1,11 @@
 package game
 
+import (
+	"fmt"
+
+	"github.com/notnil/chess"
+)
+
 type Player int
 
 const (
@@ -26,3 +32,31 @@ func (p Player) Switch() Player {
 		return PlayerWhite
 	}
 }
+
+// ParsePlayer converts a side name (e.g., "white") to a Player.
+func ParsePlayer(s string) (Player, error) {
+	switch s {
+	case "white":
+		return PlayerWhite, nil
+	case "black":
+		return PlayerBlack, nil
+	default:
+		return PlayerWhite, fmt.Errorf("unknown side %q, expected white or black", s)
+	}
+}
+
+// playerFromColor returns the player moving the pieces of the given color.
+func playerFromColor(c chess.Color) Player {
+	if c == chess.Black {
+		return PlayerBlack
+	}
+	return PlayerWhite
+}
+
+// color returns the color of the pieces moved by the player.
+func (p Player) color() chess.Color {
+	if p == PlayerBlack {
+		return chess.Black
+	}
+	return chess.White
+}
@@ -0,0 +1,209 @@
+package game
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/notnil/chess"
+)
+
+// LoadPGN reads every game from a PGN file.
+func LoadPGN(path string) ([]*chess.Game, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	return readPGNGames(file)
+}
+
+// tagPairLine matches a line holding a tag pair, e.g. [Event "Casual game"],
+// unlike a line of move text that starts with a comment like [%eval 0.35].
+var tagPairLine = regexp.MustCompile(`^\[\w+ ".*"\]$`)
+
+// readPGNGames splits a (possibly multi-game) PGN stream into games. A new
+// game starts at the first tag pair that follows move text.
+func readPGNGames(r io.Reader) ([]*chess.Game, error) {
+	var (
+		games   []*chess.Game
+		sb      strings.Builder
+		inMoves bool
+	)
+
+	flush := func() error {
+		if strings.TrimSpace(sb.String()) == "" {
+			return nil
+		}
+
+		pgn, err := chess.PGN(strings.NewReader(sb.String()))
+		if err != nil {
+			return fmt.Errorf("game %d: %w", len(games)+1, err)
+		}
+
+		games = append(games, chess.NewGame(pgn))
+		sb.Reset()
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		isTagPair := tagPairLine.MatchString(line)
+
+		if isTagPair && inMoves {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			inMoves = false
+		} else if line != "" && !isTagPair {
+			inMoves = true
+		}
+
+		sb.WriteString(line + "\n")
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	if len(games) == 0 {
+		return nil, errors.New("no games found")
+	}
+
+	return games, nil
+}
+
+// gameTitle describes a game for the game picker, e.g. "Carlsen - Nepo (1-0), WCh 2021".
+func gameTitle(i int, g *chess.Game) string {
+	tag := func(key string) string {
+		if tp := g.GetTagPair(key); tp != nil && tp.Value != "" {
+			return tp.Value
+		}
+		return unknownTag
+	}
+
+	return fmt.Sprintf("%d. %s - %s (%s), %s %s",
+		i+1, tag("White"), tag("Black"), g.Outcome(), tag("Event"), tag("Date"))
+}
+
+// SelectGame asks the user to pick one game of a multi-game PGN file.
+func SelectGame(games []*chess.Game) (*chess.Game, error) {
+	if len(games) == 1 {
+		return games[0], nil
+	}
+
+	options := make([]huh.Option[int], len(games))
+	for i, g := range games {
+		options[i] = huh.NewOption(gameTitle(i, g), i)
+	}
+
+	var index int
+	form := huh.NewSelect[int]().
+		Title("Choose a game").
+		Options(options...).
+		Value(&index)
+
+	if err := form.Run(); err != nil {
+		return nil, err
+	}
+
+	return games[index], nil
+}
+
+// handleOpenPGN prompts for a PGN file and starts replaying the chosen game.
+func (m *Model) handleOpenPGN() {
+	var path string
+	form := huh.NewInput().
+		Title("Open PGN file:").
+		Placeholder("games.pgn").
+		Validate(func(input string) error {
+			if _, err := os.Stat(input); err != nil {
+				return errors.New("file not found")
+			}
+			return nil
+		}).
+		Value(&path)
+
+	if err := form.Run(); err != nil {
+		slog.Error("input error", "err", err)
+		return
+	}
+
+	games, err := LoadPGN(path)
+	if err != nil {
+		slog.Error("error loading pgn", "path", path, "err", err)
+		m.status = "Could not open " + path + ": " + err.Error()
+		return
+	}
+
+	g, err := SelectGame(games)
+	if err != nil {
+		slog.Error("input error", "err", err)
+		return
+	}
+
+	m.startReplay(g)
+}
+
+// startReplay shows the first position of a loaded game; the arrow keys step through its moves.
+func (m *Model) startReplay(g *chess.Game) {
+	m.replay = g
+	m.review = nil
+	m.resetClock()
+	m.replayTo(0)
+	m.status = ""
+}
+
+// stopReplay leaves replay mode and keeps the shown position as the live game.
+func (m *Model) stopReplay() {
+	m.replay = nil
+	m.replayPly = 0
+}
+
+// replayTo rebuilds the game up to the given ply of the replayed game.
+func (m *Model) replayTo(ply int) {
+	moves := m.replay.Moves()
+	ply = max(0, min(ply, len(moves)))
+
+	if err := m.rebuildGame(m.replay.Positions()[0].String(), moves[:ply]); err != nil {
+		slog.Error("error replaying game", "err", err)
+		return
+	}
+
+	m.replayPly = ply
+}
+
+// handleReplayKey steps through the replayed game and reports whether the key was handled.
+func (m *Model) handleReplayKey(key string) bool {
+	switch key {
+	case "left":
+		m.replayTo(m.replayPly - 1)
+	case "right":
+		m.replayTo(m.replayPly + 1)
+	case "up":
+		m.replayTo(0)
+	case "down":
+		m.replayTo(len(m.replay.Moves()))
+	case "esc":
+		m.stopReplay()
+	default:
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,182 @@
+package game
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/notnil/chess"
+)
+
+// terminationReasons describes how a game ended for each outcome method.
+var terminationReasons = map[chess.Method]string{
+	chess.Checkmate:            "checkmate",
+	chess.Resignation:          "resignation",
+	chess.DrawOffer:            "draw agreed",
+	chess.Stalemate:            "stalemate",
+	chess.ThreefoldRepetition:  "threefold repetition",
+	chess.FivefoldRepetition:   "fivefold repetition",
+	chess.FiftyMoveRule:        "fifty-move rule",
+	chess.SeventyFiveMoveRule:  "seventy-five-move rule",
+	chess.InsufficientMaterial: "insufficient material",
+}
+
+// terminationReason returns how the game ended, e.g. "checkmate" or "timeout".
+func (m *Model) terminationReason() string {
+	if m.flagged {
+		if m.outcome() == chess.Draw {
+			return "timeout vs insufficient material"
+		}
+		return "timeout"
+	}
+
+	if reason, ok := terminationReasons[m.gameEngine.Method()]; ok {
+		return reason
+	}
+	return "unknown"
+}
+
+// resultText describes the outcome, e.g. "White wins by checkmate".
+func (m *Model) resultText() string {
+	var winner string
+	switch m.outcome() {
+	case chess.WhiteWon:
+		winner = "White wins"
+	case chess.BlackWon:
+		winner = "Black wins"
+	case chess.Draw:
+		winner = "Draw"
+	default:
+		return ""
+	}
+
+	return fmt.Sprintf("%s by %s", winner, m.terminationReason())
+}
+
+// showResult reports whether the result overlay covers the game.
+func (m *Model) showResult() bool {
+	return m.outcome() != chess.NoOutcome && !m.resultDismissed && m.replay == nil
+}
+
+// renderResult draws the result overlay with the actions available after the game.
+func (m *Model) renderResult() string {
+	return resultStyle.Render(lipgloss.JoinVertical(lipgloss.Center,
+		resultTitleStyle.Render(m.outcome().String()),
+		m.resultText(),
+		"",
+		"n: new game",
+		"r: rematch with colors swapped",
+		"s: save PGN",
+		"v: review the game",
+		"esc: look at the board",
+	))
+}
+
+// renderCompactResult draws the result overlay without its padding and with
+// the actions paired up, for a board too short for the full overlay.
+func (m *Model) renderCompactResult() string {
+	return resultStyle.Padding(0, 1).Render(lipgloss.JoinVertical(lipgloss.Center,
+		resultTitleStyle.Render(m.outcome().String()),
+		m.resultText(),
+		"n: new game  r: rematch",
+		"s: save PGN  v: review",
+		"esc: look at the board",
+	))
+}
+
+// handleResultKey runs the action chosen on the result overlay and reports whether the key was handled.
+func (m *Model) handleResultKey(key string) bool {
+	switch key {
+	case "n":
+		m.newGame(chess.StartingPosition().String())
+	case "r":
+		m.rematch()
+	case "s":
+		m.savePGN()
+	case "v":
+		m.toggleReview()
+	case "esc":
+		m.resultDismissed = true
+	default:
+		return false
+	}
+
+	return true
+}
+
+// newGame starts a fresh game from the given position with a new PGN file.
+func (m *Model) newGame(fen string) {
+	// a generated file name belongs to the previous game, a chosen one is reused
+	if m.pgnPath == DefaultPGNPath(m.startedAt) {
+		m.pgnPath = DefaultPGNPath(time.Now())
+	}
+	m.startedAt = time.Now()
+
+	if err := m.SetPosition(fen); err != nil {
+		slog.Error("error starting new game", "fen", fen, "err", err)
+		m.status = "Could not start a new game: " + err.Error()
+		return
+	}
+
+	m.status = "New game started"
+}
+
+// rematch replays the starting position of the last game with the colors swapped.
+func (m *Model) rematch() {
+	m.humanPlayer = m.humanPlayer.Switch()
+	m.orient()
+	m.newGame(m.gameEngine.Positions()[0].String())
+
+	if m.mode == ModeEngine {
+		m.status = "Rematch started, you play " + m.humanPlayer.String()
+	} else {
+		m.status = "Rematch started, players swap colors"
+	}
+}
+
+// resign gives up the game for the human, or for the player to move in
+// two-player mode, after asking for confirmation.
+func (m *Model) resign() {
+	if m.outcome() != chess.NoOutcome {
+		return
+	}
+
+	loser := m.currentPlayer
+	if m.mode == ModeEngine {
+		loser = m.humanPlayer
+	}
+
+	var confirmed bool
+	form := huh.NewConfirm().
+		Title(fmt.Sprintf("Resign the game as %s?", loser)).
+		Affirmative("Resign").
+		Negative("Cancel").
+		Value(&confirmed)
+
+	if err := form.Run(); err != nil {
+		slog.Error("input error", "err", err)
+		return
+	}
+
+	if !confirmed {
+		return
+	}
+
+	m.gameEngine.Resign(loser.color())
+	m.status = loser.String() + " resigned"
+	m.logResult()
+}
+
+// logResult records the end of the game once it is decided.
+func (m *Model) logResult() {
+	if m.outcome() == chess.NoOutcome {
+		return
+	}
+
+	slog.Info("game over",
+		"outcome", m.outcome(),
+		"termination", m.terminationReason(),
+	)
+}
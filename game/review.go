@@ -0,0 +1,388 @@
+package game
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/notnil/chess"
+	"github.com/notnil/chess/uci"
+)
+
+const (
+	reviewDepth = 14   // depth every position of a reviewed game is searched to
+	evalClip    = 1000 // centipawns an evaluation is clipped to for the centipawn loss
+)
+
+// moveClass rates a move by how much of the mover's winning chance it gave away.
+type moveClass int
+
+const (
+	classBest moveClass = iota
+	classGood
+	classInaccuracy
+	classMistake
+	classBlunder
+)
+
+// classThresholds are the losses of winning chance, from 0 to 1, from which a
+// move is an inaccuracy, a mistake or a blunder, as on lichess.org.
+var classThresholds = []struct {
+	loss  float64
+	class moveClass
+}{
+	{0.15, classBlunder},
+	{0.10, classMistake},
+	{0.05, classInaccuracy},
+}
+
+func (c moveClass) String() string {
+	return [...]string{"best", "good", "inaccuracy", "mistake", "blunder"}[c]
+}
+
+// symbol returns the move suffix annotation, e.g. "??" for a blunder.
+func (c moveClass) symbol() string {
+	return [...]string{"", "", "?!", "?", "??"}[c]
+}
+
+// nag returns the PGN numeric annotation glyph of the symbol, e.g. "$4" for "??".
+func (c moveClass) nag() string {
+	return [...]string{"", "", "$6", "$2", "$4"}[c]
+}
+
+// positionEval is the engine's verdict on a position of the reviewed game.
+type positionEval struct {
+	score uci.Score   // from white's point of view
+	best  *chess.Move // strongest move, nil when the game is over in the position
+}
+
+// moveReview is the verdict on a move of the reviewed game.
+type moveReview struct {
+	class    moveClass
+	loss     int         // centipawns the move gave away, from the mover's point of view
+	accuracy float64     // from 0 to 100
+	best     *chess.Move // engine's move in the position, tagged for SAN
+}
+
+// gameReview searches every position of a finished game and rates its moves.
+type gameReview struct {
+	positions []*chess.Position
+	moves     []*chess.Move
+	evals     []positionEval // evaluations of positions found so far, by ply
+	searching bool           // whether the engine is searching the next position
+	failed    bool           // whether the engine failed on a position
+	verdicts  []moveReview   // verdicts on the moves once every position is evaluated
+}
+
+// complete reports whether every position has been evaluated.
+func (r *gameReview) complete() bool {
+	return len(r.evals) == len(r.positions)
+}
+
+// finalEval scores a position where the game is over without asking the
+// engine, which has no move to search there. A mated side is scored as mated
+// in one, which ranks the same as mated on the board.
+func finalEval(pos *chess.Position) positionEval {
+	if pos.Status() != chess.Checkmate {
+		return positionEval{}
+	}
+
+	return positionEval{score: whiteScore(pos, uci.Score{Mate: -1})}
+}
+
+// clippedCP turns a score into centipawns within evalClip, mates at the edges.
+func clippedCP(score uci.Score) int {
+	switch {
+	case score.Mate > 0:
+		return evalClip
+	case score.Mate < 0:
+		return -evalClip
+	default:
+		return min(max(score.CP, -evalClip), evalClip)
+	}
+}
+
+// moveAccuracy turns the mover's loss of winning chance, from 0 to 1, into an
+// accuracy from 0 to 100 with the formula of lichess.org.
+func moveAccuracy(loss float64) float64 {
+	return min(max(103.1668*math.Exp(-0.04354*loss*100)-3.1669, 0), 100)
+}
+
+// rate rates the move played from ply with the evaluations before and after it.
+func (r *gameReview) rate(ply int) moveReview {
+	before, after := r.evals[ply], r.evals[ply+1]
+	sign := 1
+	if r.positions[ply].Turn() == chess.Black {
+		sign = -1
+	}
+
+	chance := func(score uci.Score) float64 {
+		if sign < 0 {
+			return 1 - whiteShare(score)
+		}
+		return whiteShare(score)
+	}
+
+	loss := max(chance(before.score)-chance(after.score), 0)
+	verdict := moveReview{
+		class:    classGood,
+		loss:     max(sign*(clippedCP(before.score)-clippedCP(after.score)), 0),
+		accuracy: moveAccuracy(loss),
+	}
+
+	if before.best != nil {
+		if best, _ := resolveLine(r.positions[ply], []*chess.Move{before.best}, 1); len(best) > 0 {
+			verdict.best = best[0]
+		}
+	}
+
+	move := r.moves[ply]
+	if verdict.best != nil && move.S1() == verdict.best.S1() && move.S2() == verdict.best.S2() && move.Promo() == verdict.best.Promo() {
+		verdict.class = classBest
+		return verdict
+	}
+
+	for _, t := range classThresholds {
+		if loss >= t.loss {
+			verdict.class = t.class
+			break
+		}
+	}
+
+	return verdict
+}
+
+// sideSummary totals the verdicts on the moves of one side.
+type sideSummary struct {
+	moves    int
+	loss     int     // total centipawn loss
+	accuracy float64 // total accuracy
+	classes  [classBlunder + 1]int
+}
+
+func (s sideSummary) averageLoss() int {
+	if s.moves == 0 {
+		return 0
+	}
+	return s.loss / s.moves
+}
+
+func (s sideSummary) averageAccuracy() float64 {
+	if s.moves == 0 {
+		return 0
+	}
+	return s.accuracy / float64(s.moves)
+}
+
+// summary totals the verdicts of the given side.
+func (r *gameReview) summary(color chess.Color) sideSummary {
+	var s sideSummary
+	for ply, v := range r.verdicts {
+		if r.positions[ply].Turn() != color {
+			continue
+		}
+
+		s.moves++
+		s.loss += v.loss
+		s.accuracy += v.accuracy
+		s.classes[v.class]++
+	}
+
+	return s
+}
+
+// toggleReview starts reviewing the game once it is over, or shows and hides
+// the review panel when the game was reviewed already. A loaded game can be
+// reviewed while it is replayed.
+func (m *Model) toggleReview() {
+	if !m.hasEngine() {
+		m.status = "Attach an engine with 'E' to review the game"
+		return
+	}
+
+	if m.review != nil {
+		m.showReview = !m.showReview
+		return
+	}
+
+	game := m.replay
+	if game == nil {
+		game = m.gameEngine
+		if m.outcome() == chess.NoOutcome {
+			m.status = "The game can be reviewed once it is over"
+			return
+		}
+	}
+	if len(game.Moves()) == 0 {
+		m.status = "No moves to review"
+		return
+	}
+
+	m.review = &gameReview{positions: game.Positions(), moves: game.Moves()}
+	m.showReview = true
+	m.status = "Reviewing the game..."
+}
+
+// reviewing reports whether a review is waiting for the engine.
+func (m *Model) reviewing() bool {
+	return m.review != nil && !m.review.complete() && !m.review.failed
+}
+
+// requestReviewSearch searches the next position of the review. Positions
+// where the game is over are scored without the engine.
+func (m *Model) requestReviewSearch() tea.Cmd {
+	if m.searchingFEN != "" {
+		// a finite search ends on its own, an infinite one has to be stopped
+		if m.searchInfinite && !m.searchStopped {
+			m.searchStopped = true
+			return stopSearch(m.analyzer)
+		}
+		return nil
+	}
+
+	r := m.review
+	for !r.complete() && r.positions[len(r.evals)].Status() != chess.NoMethod {
+		r.evals = append(r.evals, finalEval(r.positions[len(r.evals)]))
+	}
+	if r.complete() {
+		m.finishReview()
+		return nil
+	}
+
+	pos := r.positions[len(r.evals)]
+	r.searching = true
+	m.searchingFEN = pos.String()
+	m.searchStopped = false
+	m.searchingMove = false
+	return searchPosition(m.analyzer, pos, uci.CmdGo{Depth: reviewDepth})
+}
+
+// handleReviewResult records the evaluation of the searched position. The
+// best move is taken from the principal variation, since a reduced skill
+// level makes the engine play a weaker move than the one it found best.
+func (m *Model) handleReviewResult(msg searchResultMsg) {
+	r := m.review
+	r.searching = false
+	if msg.err != nil || len(msg.result.Info.PV) == 0 {
+		r.failed = true
+		m.status = "Could not review the game, the engine failed"
+		return
+	}
+
+	pos := r.positions[len(r.evals)]
+	r.evals = append(r.evals, positionEval{
+		score: whiteScore(pos, msg.result.Info.Score),
+		best:  msg.result.Info.PV[0],
+	})
+	m.status = fmt.Sprintf("Reviewing the game... %d of %d positions", len(r.evals), len(r.positions))
+	if r.complete() {
+		m.finishReview()
+	}
+}
+
+// finishReview rates the moves once every position is evaluated.
+func (m *Model) finishReview() {
+	r := m.review
+	r.verdicts = make([]moveReview, len(r.moves))
+	for ply := range r.moves {
+		r.verdicts[ply] = r.rate(ply)
+	}
+
+	m.status = "Game reviewed, moves are annotated and 's' saves the annotated PGN"
+}
+
+// reviewedMove returns the verdict on the move of the game at ply, if the
+// finished review covers it.
+func (m *Model) reviewedMove(ply int) (moveReview, bool) {
+	if m.review == nil || ply < 0 || ply >= len(m.review.verdicts) {
+		return moveReview{}, false
+	}
+
+	moves := m.gameEngine.Moves()
+	if ply >= len(moves) || moves[ply].String() != m.review.moves[ply].String() ||
+		m.gameEngine.Positions()[ply].String() != m.review.positions[ply].String() {
+		return moveReview{}, false
+	}
+
+	return m.review.verdicts[ply], true
+}
+
+// annotateMove adds the review symbol to a move in SAN, e.g. "Qxb7??".
+func (m *Model) annotateMove(ply int, san string) string {
+	if v, ok := m.reviewedMove(ply); ok {
+		return san + v.class.symbol()
+	}
+	return san
+}
+
+// pgnAnnotation returns the NAG and the comment following a reviewed move in
+// the PGN, e.g. `$2 { [%eval -1.20] Mistake. Nf3 was best. }`.
+func (m *Model) pgnAnnotation(ply int) []string {
+	v, ok := m.reviewedMove(ply)
+	if !ok {
+		return nil
+	}
+
+	var tokens []string
+	if nag := v.class.nag(); nag != "" {
+		tokens = append(tokens, nag)
+	}
+
+	var comment []string
+	if after := m.review.evals[ply+1]; after.best != nil {
+		comment = append(comment, "[%eval "+pgnEval(after.score)+"]")
+	}
+	if v.class >= classInaccuracy && v.best != nil {
+		comment = append(comment, fmt.Sprintf("%s. %s was best.",
+			strings.ToUpper(v.class.String()[:1])+v.class.String()[1:],
+			chess.AlgebraicNotation{}.Encode(m.review.positions[ply], v.best)))
+	}
+	if len(comment) > 0 {
+		tokens = append(tokens, "{ "+strings.Join(comment, " ")+" }")
+	}
+
+	return tokens
+}
+
+// pgnEval renders a score from white's point of view for an eval comment, e.g. "0.35" or "#-3".
+func pgnEval(score uci.Score) string {
+	if score.Mate != 0 {
+		return fmt.Sprintf("#%d", score.Mate)
+	}
+	return fmt.Sprintf("%.2f", float64(score.CP)/100)
+}
+
+// renderReview draws the progress of the review, then the accuracy of both
+// sides and the verdict on the move shown on the board.
+func (m *Model) renderReview() string {
+	r := m.review
+	rows := []string{analysisTitleStyle.Render("Review")}
+	switch {
+	case r.failed:
+		rows = append(rows, "the engine failed")
+	case r.verdicts == nil:
+		rows = append(rows, fmt.Sprintf("depth %d, %d of %d positions", reviewDepth, len(r.evals), len(r.positions)))
+	default:
+		for _, side := range []chess.Color{chess.White, chess.Black} {
+			s := r.summary(side)
+			rows = append(rows,
+				fmt.Sprintf("%-6s %5.1f%%  ACPL %d", playerFromColor(side), s.averageAccuracy(), s.averageLoss()),
+				fmt.Sprintf("  ?! %d  ? %d  ?? %d", s.classes[classInaccuracy], s.classes[classMistake], s.classes[classBlunder]),
+			)
+		}
+
+		if v, ok := m.reviewedMove(m.shownPly() - 1); ok {
+			row := "Last move: " + v.class.String()
+			if v.loss > 0 {
+				row += fmt.Sprintf(", -%d cp", v.loss)
+			}
+			rows = append(rows, row)
+			if v.class != classBest && v.best != nil {
+				rows = append(rows, "Best was "+chess.AlgebraicNotation{}.Encode(m.review.positions[m.shownPly()-1], v.best))
+			}
+		}
+	}
+
+	return analysisStyle.Render(strings.Join(rows, "\n"))
+}
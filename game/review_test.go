@@ -0,0 +1,192 @@
+package game
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/notnil/chess"
+	"github.com/notnil/chess/uci"
+)
+
+func TestMoveAccuracy(t *testing.T) {
+	tests := []struct {
+		loss float64
+		want float64
+	}{
+		{loss: 0, want: 100},
+		{loss: 0.05, want: 79.82},
+		{loss: 0.1, want: 63.58},
+		{loss: 0.3, want: 24.78},
+		{loss: 1, want: 0},
+	}
+
+	for _, tt := range tests {
+		if got := moveAccuracy(tt.loss); math.Abs(got-tt.want) > 0.01 {
+			t.Errorf("moveAccuracy(%v) = %.2f, want %.2f", tt.loss, got, tt.want)
+		}
+	}
+}
+
+// reviewAfter reviews the moves played from the starting position with the
+// given scores of the positions, from white's point of view.
+func reviewAfter(t *testing.T, moves []string, scores []uci.Score, best []string) *gameReview {
+	t.Helper()
+
+	game := chess.NewGame(chess.UseNotation(chess.UCINotation{}))
+	for _, move := range moves {
+		if err := game.MoveStr(move); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := &gameReview{positions: game.Positions(), moves: game.Moves()}
+	for i, score := range scores {
+		eval := positionEval{score: score}
+		if i < len(best) {
+			move, err := chess.UCINotation{}.Decode(game.Positions()[i], best[i])
+			if err != nil {
+				t.Fatal(err)
+			}
+			eval.best = move
+		}
+		r.evals = append(r.evals, eval)
+	}
+
+	return r
+}
+
+func TestRate(t *testing.T) {
+	tests := []struct {
+		name     string
+		moves    []string
+		scores   []uci.Score
+		best     []string
+		ply      int
+		want     moveClass
+		wantLoss int
+	}{
+		{
+			name:     "engine move",
+			moves:    []string{"e2e4"},
+			scores:   []uci.Score{{CP: 30}, {CP: 20}},
+			best:     []string{"e2e4"},
+			want:     classBest,
+			wantLoss: 10,
+		},
+		{
+			name:     "small loss",
+			moves:    []string{"a2a3"},
+			scores:   []uci.Score{{CP: 30}, {CP: 0}},
+			best:     []string{"e2e4"},
+			want:     classGood,
+			wantLoss: 30,
+		},
+		{
+			name:     "inaccuracy",
+			moves:    []string{"a2a3"},
+			scores:   []uci.Score{{CP: 0}, {CP: -100}},
+			best:     []string{"e2e4"},
+			want:     classInaccuracy,
+			wantLoss: 100,
+		},
+		{
+			name:     "mistake",
+			moves:    []string{"a2a3"},
+			scores:   []uci.Score{{CP: 0}, {CP: -150}},
+			best:     []string{"e2e4"},
+			want:     classMistake,
+			wantLoss: 150,
+		},
+		{
+			name:     "blunder into mate",
+			moves:    []string{"a2a3"},
+			scores:   []uci.Score{{CP: 0}, {Mate: -1}},
+			best:     []string{"e2e4"},
+			want:     classBlunder,
+			wantLoss: evalClip,
+		},
+		{
+			name:     "black blunder",
+			moves:    []string{"e2e4", "f7f6"},
+			scores:   []uci.Score{{CP: 30}, {CP: 30}, {CP: 330}},
+			best:     []string{"e2e4", "e7e5"},
+			ply:      1,
+			want:     classBlunder,
+			wantLoss: 300,
+		},
+		{
+			name:   "black gains",
+			moves:  []string{"e2e4", "f7f6"},
+			scores: []uci.Score{{CP: 30}, {CP: 300}, {CP: 30}},
+			best:   []string{"e2e4", "e7e5"},
+			ply:    1,
+			want:   classGood,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := reviewAfter(t, tt.moves, tt.scores, tt.best).rate(tt.ply)
+			if v.class != tt.want || v.loss != tt.wantLoss {
+				t.Errorf("rate() = %s losing %d cp, want %s losing %d cp", v.class, v.loss, tt.want, tt.wantLoss)
+			}
+		})
+	}
+}
+
+func TestAnnotatedPGN(t *testing.T) {
+	moves := []string{
+		"e2e4", "e7e5", "g1f3", "b8c6", "f1c4", "g8f6", "f3g5", "d7d5", "e4d5", "f6d5",
+		"g5f7", "e8f7", "d1f3", "f7e6", "b1c3", "c6b4", "f3e4", "c7c6", "a2a3", "b4a6",
+	}
+
+	m := InitialModel()
+	for _, move := range moves {
+		if !m.makeMove(move) {
+			t.Fatalf("move %s rejected", move)
+		}
+	}
+
+	// every move loses a pawn and a half for the side that played it, and the
+	// engine preferred another one
+	var scores []uci.Score
+	var best []string
+	for i, pos := range m.gameEngine.Positions() {
+		score := uci.Score{}
+		if i%2 == 1 {
+			score.CP = -150
+		}
+		scores = append(scores, score)
+
+		for _, move := range pos.ValidMoves() {
+			if i == len(moves) || move.String() != moves[i] {
+				best = append(best, move.String())
+				break
+			}
+		}
+	}
+
+	m.review = reviewAfter(t, moves, scores, best)
+	m.finishReview()
+
+	pgn := m.PGN()
+	for _, want := range []string{"1. e4 $2 { [%eval -1.50] Mistake.", "1... e5 $2", "{ [%eval 0.00] Mistake. Na6 was best. }"} {
+		if !strings.Contains(pgn, want) {
+			t.Errorf("PGN lacks %q:\n%s", want, pgn)
+		}
+	}
+	for _, line := range strings.Split(pgn, "\n") {
+		if strings.HasPrefix(line, "[%") {
+			t.Errorf("PGN line starts inside a comment: %q", line)
+		}
+	}
+
+	games, err := readPGNGames(strings.NewReader(pgn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(games) != 1 || len(games[0].Moves()) != len(moves) {
+		t.Fatalf("read back %d games, want 1 game of %d moves:\n%s", len(games), len(moves), pgn)
+	}
+}
@@ -4,49 +4,156 @@ import (
 	"github.com/charmbracelet/lipgloss"
 )
 
-// chess piece styles
+// chess piece styles, set by the theme
 var (
-	whitePieceStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#000000"))
+	whitePieceStyle lipgloss.Style
+	blackPieceStyle lipgloss.Style
+)
+
+// chess board square styles, set by the theme
+var (
+	whiteCursorStyle lipgloss.Style // Cursor on white square style
+	blackCursorStyle lipgloss.Style // Cursor on black square style
+	selectedStyle    lipgloss.Style // Selected square style
+	blackSquare      lipgloss.Style // Black square style
+	whiteSquare      lipgloss.Style // White square style
+)
+
+// last move and check styles, set by the theme
+var (
+	whiteLastMoveStyle lipgloss.Style // From and to squares of the last move on a white square
+	blackLastMoveStyle lipgloss.Style // From and to squares of the last move on a black square
+	checkStyle         lipgloss.Style // Square of the king in check
+)
+
+// eval bar styles, set by the theme
+var (
+	evalWhiteStyle lipgloss.Style // White's share of the eval bar
+	evalBlackStyle lipgloss.Style // Black's share of the eval bar
+)
+
+// move target styles, set by the theme
+var (
+	targetStyle   lipgloss.Style // Dot and ring marking where the selected piece can move
+	rejectedStyle lipgloss.Style // Destination of a rejected illegal move
+)
+
+// highlight mark styles, set by the theme
+var (
+	markStyle      lipgloss.Style // Marks beside the piece on a highlighted square
+	markHighlights bool           // Whether highlighted squares are drawn with their marks
+)
+
+// best move hint styles, set by the theme
+var (
+	whiteHintStyle lipgloss.Style // From and to squares of the best move on a white square
+	blackHintStyle lipgloss.Style // From and to squares of the best move on a black square
+	hintPathStyle  lipgloss.Style // Marker on the squares a sliding piece passes
+)
+
+func init() {
+	applyTheme(themes[DefaultThemeName])
+}
+
+// applyTheme sets the board styles from the colors of the theme.
+func applyTheme(t Theme) {
+	square := func(background, foreground string) lipgloss.Style {
+		return lipgloss.NewStyle().
+			Background(lipgloss.Color(background)).
+			Foreground(lipgloss.Color(foreground)).
+			Align(lipgloss.Center).
+			Padding(1, 3)
+	}
 
-	blackPieceStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#000000"))
+	whitePieceStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(t.WhitePiece)).Bold(true)
+	blackPieceStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(t.BlackPiece)).Bold(true)
+
+	whiteCursorStyle = square(t.LightCursor, t.DarkSquare)
+	blackCursorStyle = square(t.DarkCursor, t.LightSquare)
+	selectedStyle = square(t.Selected, t.Selected)
+	blackSquare = square(t.DarkSquare, t.LightSquare)
+	whiteSquare = square(t.LightSquare, t.DarkSquare)
+
+	whiteLastMoveStyle = square(t.LastMoveLight, t.DarkSquare)
+	blackLastMoveStyle = square(t.LastMoveDark, t.LightSquare)
+	checkStyle = square(t.Check, t.LightSquare)
+
+	evalWhiteStyle = lipgloss.NewStyle().Background(lipgloss.Color(t.WhitePiece))
+	evalBlackStyle = lipgloss.NewStyle().Background(lipgloss.Color(t.BlackPiece))
+
+	targetStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(t.Target)).Bold(true)
+	rejectedStyle = square(t.Rejected, t.DarkSquare)
+
+	markStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(t.Target)).Bold(true)
+	markHighlights = t.Marks
+
+	whiteHintStyle = square(t.HintLight, t.DarkSquare)
+	blackHintStyle = square(t.HintDark, t.LightSquare)
+	hintPathStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(t.HintPath)).Bold(true)
+}
+
+// board label styles
+var (
+	// Rank and file labels and the title
+	labelStyle = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("241")).
+		Align(lipgloss.Center)
 )
 
-// chess board square styles
+// move history styles
 var (
-	// Cursor on white square style
-	whiteCursorStyle = lipgloss.NewStyle().
+	// Move shown on the board, highlighted in the history panel
+	currentMoveStyle = lipgloss.NewStyle().
+				Background(lipgloss.Color("#a1eb8d")).
+				Foreground(lipgloss.Color("#000000"))
+
+	// Banner shown above the board while browsing earlier positions
+	historyIndicatorStyle = lipgloss.NewStyle().
 				Background(lipgloss.Color("#e3d5ca")).
 				Foreground(lipgloss.Color("#000000")).
-				Align(lipgloss.Center).
-				Padding(1, 3)
+				Bold(true)
+)
+
+// chess clock styles
+var (
+	// Clock of the player waiting for the opponent
+	clockStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("241")).
+			Padding(0, 1)
 
-	// Cursor on black square style
-	blackCursorStyle = lipgloss.NewStyle().
-				Background(lipgloss.Color("#81b583")).
+	// Clock of the player to move
+	activeClockStyle = lipgloss.NewStyle().
+				Background(lipgloss.Color("#4e7837")).
 				Foreground(lipgloss.Color("#ffffff")).
-				Align(lipgloss.Center).
-				Padding(1, 3)
+				Bold(true).
+				Padding(0, 1)
+)
 
-	// Selected square style
-	selectedStyle = lipgloss.NewStyle().
-			Background(lipgloss.Color("#a1eb8d")).
-			Foreground(lipgloss.Color("#a1eb8d")).
-			Align(lipgloss.Center).
-			Padding(1, 3)
+// game result styles
+var (
+	// Box announcing the result once the game is over
+	resultStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("#4e7837")).
+			Padding(1, 3).
+			Align(lipgloss.Center)
 
-	// Black square style
-	blackSquare = lipgloss.NewStyle().
-			Background(lipgloss.Color("#4e7837")).
-			Foreground(lipgloss.Color("#ffffff")).
-			Align(lipgloss.Center).
-			Padding(1, 3)
+	// Score line of the result box, e.g. 1-0
+	resultTitleStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#a1eb8d")).
+				Bold(true)
+)
 
-	// White square style
-	whiteSquare = lipgloss.NewStyle().
-			Background(lipgloss.Color("#ffffff")).
-			Foreground(lipgloss.Color("#4e7837")).
-			Align(lipgloss.Center).
-			Padding(1, 3)
+// engine analysis styles
+var (
+	// Panel with the search statistics of the engine
+	analysisStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("241")).
+			Padding(0, 1)
+
+	// Title of the analysis panel
+	analysisTitleStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#a1eb8d")).
+				Bold(true)
 )
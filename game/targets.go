@@ -0,0 +1,66 @@
+package game
+
+import (
+	"github.com/notnil/chess"
+)
+
+// square is a board coordinate as (row, column), the order used by Board.Get.
+type square [2]int
+
+// legalTargets returns the squares the selected piece can move to and whether
+// the move captures there. It is empty when no piece is selected.
+func (m *Model) legalTargets() map[square]bool {
+	if !m.selected || m.viewing {
+		return nil
+	}
+
+	from := Position(m.selectedY, m.selectedX)
+	targets := map[square]bool{}
+	for _, v := range m.gameEngine.ValidMoves() {
+		if v.S1().String() != from {
+			continue
+		}
+
+		x, y := coordinates(v.S2().String())
+		targets[square{x, y}] = v.HasTag(chess.Capture) || v.HasTag(chess.EnPassant)
+	}
+
+	return targets
+}
+
+// boardRows renders the shown position for the board table, with a dot on
+// the quiet moves of the selected piece, a ring around its captures, a
+// marker on the squares the hinted move passes and the marks of the
+// highlighted squares when the theme draws them.
+func (m *Model) boardRows(targets map[square]bool, hintPath []square, highlights [boardSize][boardSize]highlight) [][]string {
+	board := m.displayBoard()
+	rows := board.Display()
+	for _, sq := range hintPath {
+		rows[sq[0]][sq[1]] = hintPathStyle.Render("·")
+	}
+	for sq, capture := range targets {
+		if capture {
+			rows[sq[0]][sq[1]] = targetStyle.Render("(") + board.Get(sq[0], sq[1]).Render() + targetStyle.Render(")")
+		} else {
+			rows[sq[0]][sq[1]] = targetStyle.Render("•")
+		}
+	}
+	for x := range rows {
+		for y := range rows[x] {
+			// the ring around a capture takes the room of the marks
+			if h := highlights[x][y]; h.marked() && !targets[square{x, y}] {
+				rows[x][y] = h.mark(rows[x][y])
+			}
+		}
+	}
+
+	return rows
+}
+
+// rejectMove flags the destination of an illegal move on the board and tells
+// the player why nothing happened.
+func (m *Model) rejectMove(move string) {
+	m.rejected = true
+	m.rejectedX, m.rejectedY = m.cursorX, m.cursorY
+	m.status = "Illegal move " + move + ", pick one of the marked squares"
+}
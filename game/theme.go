@@ -0,0 +1,223 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// DefaultThemeName is the theme the board is drawn with unless another is chosen.
+const DefaultThemeName = "green"
+
+// Theme is the palette the board is drawn with. Colors are anything
+// lipgloss accepts, e.g. "#4e7837" or an ANSI color number like "241".
+type Theme struct {
+	Name          string `toml:"name" json:"name"`
+	LightSquare   string `toml:"light_square" json:"light_square"`
+	DarkSquare    string `toml:"dark_square" json:"dark_square"`
+	LightCursor   string `toml:"light_cursor" json:"light_cursor"`
+	DarkCursor    string `toml:"dark_cursor" json:"dark_cursor"`
+	Selected      string `toml:"selected" json:"selected"`
+	WhitePiece    string `toml:"white_piece" json:"white_piece"`
+	BlackPiece    string `toml:"black_piece" json:"black_piece"`
+	LastMoveLight string `toml:"last_move_light" json:"last_move_light"`
+	LastMoveDark  string `toml:"last_move_dark" json:"last_move_dark"`
+	Check         string `toml:"check" json:"check"`
+	Target        string `toml:"target" json:"target"`
+	Rejected      string `toml:"rejected" json:"rejected"`
+	HintLight     string `toml:"hint_light" json:"hint_light"`
+	HintDark      string `toml:"hint_dark" json:"hint_dark"`
+	HintPath      string `toml:"hint_path" json:"hint_path"`
+	// Marks draws a glyph on each side of the piece on highlighted squares,
+	// for palettes whose highlights are hard to tell apart by color alone.
+	Marks bool `toml:"marks" json:"marks"`
+}
+
+// themes are the built-in palettes plus the ones loaded with LoadThemes, by name.
+var themes = map[string]Theme{
+	"green": {
+		Name:          "green",
+		LightSquare:   "#a3c585",
+		DarkSquare:    "#4e7837",
+		LightCursor:   "#e3d5ca",
+		DarkCursor:    "#81b583",
+		Selected:      "#a1eb8d",
+		WhitePiece:    "#ffffff",
+		BlackPiece:    "#000000",
+		LastMoveLight: "#f6f087",
+		LastMoveDark:  "#a9a238",
+		Check:         "#d64541",
+		Target:        "#e76f51",
+		Rejected:      "#e76f51",
+		HintLight:     "#9ad0ec",
+		HintDark:      "#3f88c5",
+		HintPath:      "#1d4e89",
+	},
+	"brown": {
+		Name:          "brown",
+		LightSquare:   "#d2a56d",
+		DarkSquare:    "#8b5a2b",
+		LightCursor:   "#ecd3a4",
+		DarkCursor:    "#b07d4a",
+		Selected:      "#f6f669",
+		WhitePiece:    "#ffffff",
+		BlackPiece:    "#000000",
+		LastMoveLight: "#e6d36a",
+		LastMoveDark:  "#a8932e",
+		Check:         "#d64541",
+		Target:        "#3b6ea5",
+		Rejected:      "#d64541",
+		HintLight:     "#a8d5e2",
+		HintDark:      "#4a8fb0",
+		HintPath:      "#1d4e89",
+	},
+	"blue": {
+		Name:          "blue",
+		LightSquare:   "#8ca2ad",
+		DarkSquare:    "#4b6b82",
+		LightCursor:   "#c3d4dd",
+		DarkCursor:    "#6e90a8",
+		Selected:      "#9bc7e8",
+		WhitePiece:    "#ffffff",
+		BlackPiece:    "#000000",
+		LastMoveLight: "#c9c66d",
+		LastMoveDark:  "#8d8a3e",
+		Check:         "#d64541",
+		Target:        "#f4a261",
+		Rejected:      "#d64541",
+		HintLight:     "#b8e0a8",
+		HintDark:      "#5c9e4a",
+		HintPath:      "#2d6a1f",
+	},
+	// the squares of high-contrast and monochrome are mid-tones, so that white
+	// and black pieces both stand out at about 3:1 or more on every square;
+	// the greys left for the monochrome highlights are too close to tell apart,
+	// so they are marked with glyphs as well
+	"high-contrast": {
+		Name:          "high-contrast",
+		LightSquare:   "#8f8f8f",
+		DarkSquare:    "#595959",
+		LightCursor:   "#8a7d00",
+		DarkCursor:    "#b35900",
+		Selected:      "#00878f",
+		WhitePiece:    "#ffffff",
+		BlackPiece:    "#000000",
+		LastMoveLight: "#3d9140",
+		LastMoveDark:  "#2e6b30",
+		Check:         "#d40000",
+		Target:        "#ff00ff",
+		Rejected:      "#d40000",
+		HintLight:     "#5a7fd6",
+		HintDark:      "#3a5bb8",
+		HintPath:      "#ffff00",
+	},
+	"monochrome": {
+		Name:          "monochrome",
+		LightSquare:   "246",
+		DarkSquare:    "241",
+		LightCursor:   "249",
+		DarkCursor:    "244",
+		Selected:      "238",
+		WhitePiece:    "231",
+		BlackPiece:    "16",
+		LastMoveLight: "243",
+		LastMoveDark:  "239",
+		Check:         "248",
+		Target:        "16",
+		Rejected:      "240",
+		HintLight:     "242",
+		HintDark:      "245",
+		HintPath:      "231",
+		Marks:         true,
+	},
+}
+
+// ThemeNames returns the names of the available themes in alphabetical order.
+func ThemeNames() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return names
+}
+
+// SetTheme draws the board with the named theme.
+func SetTheme(name string) error {
+	theme, ok := themes[name]
+	if !ok {
+		return fmt.Errorf("unknown theme %q, expected one of %s", name, strings.Join(ThemeNames(), ", "))
+	}
+
+	applyTheme(theme)
+	return nil
+}
+
+// themeFile is the layout of a file with user themes.
+type themeFile struct {
+	Themes []Theme `toml:"themes" json:"themes"`
+}
+
+// LoadThemes adds the themes defined in a TOML or JSON file, chosen by the
+// file extension. Colors a theme leaves out are taken from the default theme.
+func LoadThemes(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var file themeFile
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".toml":
+		err = toml.Unmarshal(data, &file)
+	case ".json":
+		err = json.Unmarshal(data, &file)
+	default:
+		return fmt.Errorf("unknown theme file type %q, expected .toml or .json", ext)
+	}
+	if err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	for _, theme := range file.Themes {
+		if theme.Name == "" {
+			return fmt.Errorf("parse %s: every theme needs a name", path)
+		}
+		themes[theme.Name] = withDefaults(theme, themes[DefaultThemeName])
+	}
+
+	return nil
+}
+
+// withDefaults fills the colors missing from the theme with those of base.
+func withDefaults(theme, base Theme) Theme {
+	fill := func(color *string, fallback string) {
+		if *color == "" {
+			*color = fallback
+		}
+	}
+
+	fill(&theme.LightSquare, base.LightSquare)
+	fill(&theme.DarkSquare, base.DarkSquare)
+	fill(&theme.LightCursor, base.LightCursor)
+	fill(&theme.DarkCursor, base.DarkCursor)
+	fill(&theme.Selected, base.Selected)
+	fill(&theme.WhitePiece, base.WhitePiece)
+	fill(&theme.BlackPiece, base.BlackPiece)
+	fill(&theme.LastMoveLight, base.LastMoveLight)
+	fill(&theme.LastMoveDark, base.LastMoveDark)
+	fill(&theme.Check, base.Check)
+	fill(&theme.Target, base.Target)
+	fill(&theme.Rejected, base.Rejected)
+	fill(&theme.HintLight, base.HintLight)
+	fill(&theme.HintDark, base.HintDark)
+	fill(&theme.HintPath, base.HintPath)
+
+	return theme
+}
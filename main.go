@@ -1,44 +1,210 @@
 package main
 
 import (
-	"log/slog"
+	"flag"
+	"fmt"
 	"os"
+	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/notnil/chess/uci"
 
 	"termchess/game"
 )
 
 func main() {
-	file, err := os.OpenFile(".log/chess.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	settings := game.DefaultEngineSettings()
+	cfg := defaultConfig()
+
+	configPath := flag.String("config", defaultConfigPath(), "read defaults from this TOML config file")
+	flag.StringVar(&cfg.Mode, "mode", cfg.Mode, "game mode: two-player or engine")
+	sideName := flag.String("side", "", "side to play against the engine: white or black (asks when empty)")
+	flag.StringVar(&cfg.Engine.Path, "engine", cfg.Engine.Path, "UCI engine executable, looked up in PATH when it has no slash")
+	flag.IntVar(&cfg.Engine.Threads, "threads", cfg.Engine.Threads, "engine threads, 0 for the engine default")
+	flag.IntVar(&cfg.Engine.Hash, "hash", cfg.Engine.Hash, "engine hash table size in MB, 0 for the engine default")
+	flag.IntVar(&cfg.Engine.MultiPV, "multipv", cfg.Engine.MultiPV, "number of lines the engine analyses, 0 for the engine default")
+	flag.DurationVar(&settings.MoveTime, "movetime", settings.MoveTime, "time the engine may think per move")
+	flag.IntVar(&settings.Depth, "depth", settings.Depth, "maximum engine search depth in plies, 0 for no limit")
+	flag.IntVar(&settings.SkillLevel, "skill", settings.SkillLevel, "engine skill level (0-20), -1 for the engine default")
+	flag.IntVar(&settings.Elo, "elo", settings.Elo, "limit the engine to this Elo rating, 0 for full strength")
+	flag.StringVar(&cfg.Log.File, "log", cfg.Log.File, "write the log to this file")
+	flag.StringVar(&cfg.Log.Level, "loglevel", cfg.Log.Level, "log level: debug, info, warn or error")
+	savePath := flag.String("save", "", "save the game as PGN to this file on exit ('s' saves it in game)")
+	pgnPath := flag.String("pgn", "", "replay a game from this PGN file")
+	fen := flag.String("fen", "", "start from the position described by this FEN")
+	timeControl := flag.String("time", "", "time control as minutes+seconds, e.g. 5+3 or 15+10 (asks when empty, 'none' for no clock)")
+	autoFlip := flag.Bool("autoflip", true, "draw the board from black's side when playing black against the engine")
+	delayName := flag.String("delay", game.DelayFischer.String(), "time added per move: fischer or bronstein")
+	flag.StringVar(&cfg.Theme, "theme", cfg.Theme, "board theme: "+strings.Join(game.ThemeNames(), ", ")+" or one from -themes")
+	flag.StringVar(&cfg.Themes, "themes", cfg.Themes, "load user themes from this TOML or JSON file")
+	flag.StringVar(&cfg.Pieces, "pieces", cfg.Pieces, "piece glyphs: "+strings.Join(game.PieceSetNames(), ", "))
+	flag.Parse()
+
+	// the config file only fills in defaults, so the command line is parsed
+	// again on top of it to take precedence
+	configRequired := false
+	flag.Visit(func(f *flag.Flag) {
+		configRequired = configRequired || f.Name == "config"
+	})
+	if err := loadConfig(*configPath, configRequired, &cfg); err != nil {
+		usageError("config", err)
+	}
+	_ = flag.CommandLine.Parse(os.Args[1:])
+
+	logFile, err := setupLogging(cfg.Log)
 	if err != nil {
-		panic(err)
+		usageError("loglevel", err)
+	}
+	if logFile != nil {
+		defer func() {
+			_ = logFile.Close()
+		}()
 	}
 
-	defer func() {
-		_ = file.Close()
-	}()
+	if cfg.Themes != "" {
+		if err := game.LoadThemes(cfg.Themes); err != nil {
+			usageError("themes", err)
+		}
+	}
+	if err := game.SetTheme(cfg.Theme); err != nil {
+		usageError("theme", err)
+	}
+	if err := game.SetPieceSet(cfg.Pieces); err != nil {
+		usageError("pieces", err)
+	}
+
+	mode, err := game.ParseMode(cfg.Mode)
+	if err != nil {
+		usageError("mode", err)
+	}
+
+	if *fen != "" {
+		if err := game.ValidateFEN(*fen); err != nil {
+			usageError("fen", err)
+		}
+	}
 
-	logger := slog.New(slog.NewTextHandler(file, nil))
-	slog.SetLogLoggerLevel(slog.LevelInfo)
-	slog.SetDefault(logger)
+	delay, err := game.ParseDelayMode(*delayName)
+	if err != nil {
+		usageError("delay", err)
+	}
 
-	// set up engine to use stockfish exe
-	eng, err := uci.New("stockfish/stockfish")
+	analyzer, err := startEngine(cfg.Engine)
 	if err != nil {
-		panic(err)
+		fatal(err)
+	}
+	if _, none := analyzer.(game.NoAnalyzer); none && mode == game.ModeEngine {
+		fmt.Fprintf(os.Stderr, "termchess: engine %q not available, playing two-player instead\n", cfg.Engine.Path)
+		mode = game.ModeTwoPlayer
 	}
-	defer eng.Close()
 
-	// initialize uci with new game
-	if err := eng.Run(uci.CmdUCI, uci.CmdIsReady, uci.CmdUCINewGame); err != nil {
-		panic(err)
+	opts := []game.Option{
+		game.WithAnalyzer(analyzer),
+		game.WithEngineSetup(cfg.Engine.Path, settings, cfg.Engine.setOptionCmds()...),
+		game.WithMultiPV(cfg.Engine.MultiPV),
+	}
+	if *savePath != "" {
+		opts = append(opts, game.WithPGNPath(*savePath))
+	}
+
+	if *fen != "" {
+		opts = append(opts, game.WithFEN(*fen))
+	}
+
+	if *pgnPath != "" {
+		games, err := game.LoadPGN(*pgnPath)
+		if err != nil {
+			usageError("pgn", err)
+		}
+
+		g, err := game.SelectGame(games)
+		if err != nil {
+			fatal(err)
+		}
+
+		opts = append(opts, game.WithReplay(g))
+	}
+
+	tc, err := parseTimeControl(*timeControl, delay, *pgnPath != "")
+	if err != nil {
+		usageError("time", err)
+	}
+	if tc != nil {
+		opts = append(opts, game.WithTimeControl(*tc))
+	}
+
+	if *autoFlip {
+		opts = append(opts, game.WithAutoFlip())
+	}
+
+	if mode == game.ModeEngine {
+		side, err := parseSide(*sideName)
+		if err != nil && *sideName != "" {
+			usageError("side", err)
+		}
+		if err != nil {
+			fatal(err)
+		}
+
+		if err := analyzer.SetOptions(settings.SetOptionCmds()...); err != nil {
+			fatal(err)
+		}
+
+		opts = append(opts, game.WithEngineOpponent(side, settings))
 	}
 
 	// Start the TUI program
-	p := tea.NewProgram(game.InitialModel(eng), tea.WithAltScreen(), tea.WithMouseAllMotion())
-	if _, err = p.Run(); err != nil {
-		panic(err)
+	model := game.InitialModel(opts...)
+	p := tea.NewProgram(model, tea.WithAltScreen(), tea.WithMouseAllMotion())
+	_, err = p.Run()
+	if err == nil && *savePath != "" {
+		err = model.SavePGN(*savePath)
 	}
+
+	_ = model.Close()
+	if err != nil {
+		fatal(err)
+	}
+}
+
+// usageError reports an invalid command line flag and exits like the flag package does.
+func usageError(name string, err error) {
+	fmt.Fprintf(os.Stderr, "invalid value for flag -%s: %v\n", name, err)
+	flag.Usage()
+	os.Exit(2)
+}
+
+// fatal reports an error that ends the program before or after the game.
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "termchess: %v\n", err)
+	os.Exit(1)
+}
+
+// parseTimeControl returns the time control named on the command line, asking
+// in the start menu when none was given. Replayed games are shown without a clock.
+func parseTimeControl(s string, delay game.DelayMode, replay bool) (*game.TimeControl, error) {
+	switch {
+	case s == "none":
+		return nil, nil
+	case s == "" && replay:
+		return nil, nil
+	case s == "":
+		return game.SelectTimeControl()
+	}
+
+	tc, err := game.ParseTimeControl(s)
+	if err != nil {
+		return nil, err
+	}
+	tc.Delay = delay
+
+	return &tc, nil
+}
+
+// parseSide returns the side named on the command line, asking the user when none was given.
+func parseSide(name string) (game.Player, error) {
+	if name == "" {
+		return game.SelectSide()
+	}
+
+	return game.ParsePlayer(name)
 }